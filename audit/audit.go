@@ -0,0 +1,280 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go_wallet/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// 审计日志在数据目录下的文件名。日志每行一条 JSON 记录，头文件保存最后一条记录的序号和哈希，用于发现日志末尾被截断。
+const (
+	FileName = "audit.log"
+	HeadName = "audit.head"
+	lockName = "audit.lock"
+)
+
+// 记录的操作类型。
+const (
+	OpDecryptKey    = "decrypt_key"     // 解密密钥文件
+	OpSignTx        = "sign_tx"         // 签名交易
+	OpSignMessage   = "sign_message"    // EIP-191 消息签名
+	OpSignTypedData = "sign_typed_data" // EIP-712 结构化数据签名
+)
+
+// ResultOK 是操作成功时记录的结果，失败时记录错误信息。
+const ResultOK = "ok"
+
+// lockTimeout 是等待其他进程释放日志锁的最长时间，超过 staleLock 未释放的锁视为进程异常退出后遗留的锁。
+const (
+	lockTimeout = 10 * time.Second
+	staleLock   = 30 * time.Second
+)
+
+// Entry 是一条审计记录，只记录操作的元数据，不包含密码、私钥或消息原文。
+type Entry struct {
+	Seq     uint64         `json:"seq"`
+	Time    string         `json:"time"` // UTC，RFC 3339 格式
+	Op      string         `json:"op"`
+	Account common.Address `json:"account"`
+	ChainID uint64         `json:"chain_id,omitempty"`
+	Digest  common.Hash    `json:"digest"` // 交易哈希（未签名时为待签名哈希）或消息摘要
+	Result  string         `json:"result"`
+	Prev    string         `json:"prev"` // 上一条记录的哈希，第一条记录为空
+	Hash    string         `json:"hash"` // 本条记录除 Hash 以外所有字段的 SHA-256
+}
+
+// head 是头文件的内容。
+type head struct {
+	Seq  uint64 `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// computeHash 计算记录的哈希，Hash 字段本身不参与计算。
+func (e Entry) computeHash() (string, error) {
+	e.Hash = ""
+	content, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Append 向数据目录下的审计日志追加一条记录，并更新头文件。
+// 参数:
+//
+//	datadir - 数据目录。
+//	op - 操作类型，例如 OpSignTx。
+//	account - 操作的账户。
+//	chainID - 交易的链 ID，与链无关的操作为 0。
+//	digest - 交易哈希或消息摘要，可以为空。
+//	opErr - 操作的结果，nil 表示成功。
+//
+// 返回值:
+//
+//	如果无法写入日志，则返回错误信息；调用方应当放弃本次操作的结果。
+func Append(datadir, op string, account common.Address, chainID uint64, digest common.Hash, opErr error) error {
+	unlock, err := lock(datadir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	h, err := readHead(datadir)
+	if err != nil {
+		return err
+	}
+	result := ResultOK
+	if opErr != nil {
+		result = opErr.Error()
+	}
+	e := Entry{
+		Seq:     h.Seq + 1,
+		Time:    time.Now().UTC().Format(time.RFC3339Nano),
+		Op:      op,
+		Account: account,
+		ChainID: chainID,
+		Digest:  digest,
+		Result:  result,
+		Prev:    h.Hash,
+	}
+	if e.Hash, err = e.computeHash(); err != nil {
+		return err
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(datadir, FileName), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	content, err := json.Marshal(head{Seq: e.Seq, Hash: e.Hash})
+	if err != nil {
+		return err
+	}
+	return utils.WriteKeyFile(filepath.Join(datadir, HeadName), content)
+}
+
+// readHead 读取头文件，日志还不存在时返回空的头。
+func readHead(datadir string) (head, error) {
+	var h head
+	content, err := os.ReadFile(filepath.Join(datadir, HeadName))
+	if errors.Is(err, os.ErrNotExist) {
+		// 头文件丢失但日志存在时不能从头开始，否则会掩盖日志被篡改的事实。
+		if _, err := os.Stat(filepath.Join(datadir, FileName)); err == nil {
+			return h, fmt.Errorf("audit head %s is missing", filepath.Join(datadir, HeadName))
+		}
+		return h, nil
+	}
+	if err != nil {
+		return h, err
+	}
+	if err := json.Unmarshal(content, &h); err != nil {
+		return h, fmt.Errorf("invalid audit head: %w", err)
+	}
+	return h, nil
+}
+
+// lock 通过独占创建锁文件防止多个进程同时追加日志，返回释放锁的函数。
+func lock(datadir string) (func(), error) {
+	file := filepath.Join(datadir, lockName)
+	unlock, err := utils.LockFile(file, lockTimeout, staleLock)
+	if errors.Is(err, utils.ErrLocked) {
+		return nil, fmt.Errorf("audit log is locked by another process, remove %s if no other wallet is running", file)
+	}
+	return unlock, err
+}
+
+// Problem 是校验审计日志时发现的一个问题，Line 为 0 表示与具体行无关。
+type Problem struct {
+	Line    int
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return p.Message
+	}
+	return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+}
+
+// Report 是审计日志的校验结果。
+type Report struct {
+	Entries  []Entry
+	Problems []Problem
+}
+
+// OK 判断日志是否完整且未被修改。
+func (r *Report) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Last 返回最后一条记录的哈希，日志为空时返回空字符串。
+func (r *Report) Last() string {
+	if len(r.Entries) == 0 {
+		return ""
+	}
+	return r.Entries[len(r.Entries)-1].Hash
+}
+
+// Verify 校验数据目录下的审计日志：每条记录的哈希、与上一条记录的链接和连续的序号，
+// 并将最后一条记录与头文件比较，发现删除、插入、修改或末尾被截断的记录。
+// 参数:
+//
+//	datadir - 数据目录。
+//	expect - 之前在别处保存的某条记录的哈希，不为空时检查日志中仍然包含该记录，可以发现连同头文件一起被改写的日志。
+//
+// 返回值:
+//
+//	*Report - 校验结果，包括所有解析成功的记录和发现的问题。
+//	error - 如果无法读取日志，则返回错误信息。
+func Verify(datadir, expect string) (*Report, error) {
+	report := &Report{}
+	problem := func(line int, format string, args ...interface{}) {
+		report.Problems = append(report.Problems, Problem{Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	f, err := os.Open(filepath.Join(datadir, FileName))
+	if errors.Is(err, os.ErrNotExist) {
+		if _, err := os.Stat(filepath.Join(datadir, HeadName)); err == nil {
+			problem(0, "audit log %s is missing but its head exists", FileName)
+		}
+		return report, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	prev, seq := "", uint64(0)
+	found := expect == ""
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			problem(line, "empty line")
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(text, &e); err != nil {
+			problem(line, "invalid entry: %v", err)
+			continue
+		}
+		if hash, err := e.computeHash(); err != nil || hash != e.Hash {
+			problem(line, "entry %d was modified, hash mismatch", e.Seq)
+		}
+		if e.Seq != seq+1 {
+			problem(line, "expected entry %d, found %d, entries were removed or inserted", seq+1, e.Seq)
+		}
+		if e.Prev != prev {
+			problem(line, "entry %d does not link to the previous entry", e.Seq)
+		}
+		if strings.EqualFold(e.Hash, expect) {
+			found = true
+		}
+		prev, seq = e.Hash, e.Seq
+		report.Entries = append(report.Entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	h, err := readHead(datadir)
+	switch {
+	case err != nil:
+		problem(0, "%v", err)
+	case h.Seq != seq || h.Hash != prev:
+		problem(0, "head records entry %d (%s) but the log ends at entry %d, the log was truncated or rewritten", h.Seq, h.Hash, seq)
+	}
+	if !found {
+		problem(0, "checkpoint %s not found in the log", expect)
+	}
+	return report, nil
+}
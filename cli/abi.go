@@ -0,0 +1,433 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go_wallet/signatures"
+	"go_wallet/sol"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// bundledABIs 是随钱包附带的合约绑定，其签名总是可以被识别。
+var bundledABIs = map[string]*bind.MetaData{
+	"Token":      sol.TokenMetaData,
+	"ERC20":      sol.ERC20MetaData,
+	"Multicall3": sol.Multicall3MetaData,
+}
+
+// loadSignatures 加载数据目录下的签名数据库，并加入随钱包附带的合约签名。
+func (c *Client) loadSignatures() (*signatures.DB, error) {
+	db, err := signatures.Load(filepath.Join(c.dataDir, signatures.FileName))
+	if err != nil {
+		return nil, err
+	}
+	for name, meta := range bundledABIs {
+		parsed, err := meta.GetAbi()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s abi: %w", name, err)
+		}
+		db.AddABI(parsed)
+	}
+	return db, nil
+}
+
+// decodeHex 解析十六进制输入，允许省略 0x 前缀和首尾空白。
+func decodeHex(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+		s = "0x" + s
+	}
+	if s == "0x" {
+		return nil, nil
+	}
+	return hexutil.Decode(s)
+}
+
+// resolveMethod 按 -abi 和 -method 参数确定方法：指定 ABI 文件时在 ABI 中查找，否则 methodName 必须是完整的文本签名。
+func resolveMethod(abiFile, methodName string) (abi.Method, error) {
+	if abiFile != "" {
+		parsed, err := loadABI(abiFile)
+		if err != nil {
+			return abi.Method{}, err
+		}
+		return findMethod(parsed, methodName)
+	}
+	if !strings.Contains(methodName, "(") {
+		return abi.Method{}, fmt.Errorf("without -abi, -method must be a full signature like transfer(address,uint256), got %q", methodName)
+	}
+	return signatures.ParseMethod(methodName)
+}
+
+// printArgs 按 ABI 参数打印解码后的值，每行一个：参数名 (类型): 值。
+func printArgs(indent string, args abi.Arguments, values []interface{}) {
+	for i, arg := range args {
+		name := arg.Name
+		if name == "" {
+			name = fmt.Sprintf("[%d]", i)
+		}
+		typ := arg.Type.String()
+		if arg.Indexed {
+			typ += ", indexed"
+		}
+		fmt.Printf("%s%s (%s): %s\n", indent, name, typ, formatABIValue(arg.Type, reflect.ValueOf(values[i])))
+	}
+}
+
+// abiencode 将方法和参数编码为调用数据。
+// 参数:
+//
+//	abiFile - 合约 ABI 文件，为空时 methodName 必须是完整的文本签名。
+//	methodName - 方法名或签名。
+//	args - 方法参数，格式与 call 相同。
+//
+// 返回值:
+//
+//	string - 十六进制的调用数据，包含 4 字节选择器。
+//	error - 如果解析或编码过程中发生错误，则返回错误。
+func (c *Client) abiencode(abiFile, methodName string, args []string) (string, error) {
+	method, err := resolveMethod(abiFile, methodName)
+	if err != nil {
+		return "", err
+	}
+	values, err := parseABIArgs(method, args)
+	if err != nil {
+		return "", err
+	}
+	packed, err := method.Inputs.Pack(values...)
+	if err != nil {
+		return "", err
+	}
+	return hexutil.Encode(append(common.CopyBytes(method.ID), packed...)), nil
+}
+
+// abidecode 解码调用数据，打印方法签名和参数。
+// 没有指定 ABI 文件和方法时，根据选择器在签名数据库中查找；选择器冲突时，
+// 只打印解码后重新编码与原数据一致的签名。
+// 参数:
+//
+//	abiFile - 合约 ABI 文件，可以为空。
+//	methodName - 方法名或签名，可以为空。
+//	data - 十六进制的调用数据。
+//
+// 返回值:
+//
+//	如果无法识别或解码调用数据，则返回错误。
+func (c *Client) abidecode(abiFile, methodName, data string) error {
+	calldata, err := decodeHex(data)
+	if err != nil {
+		return fmt.Errorf("invalid calldata: %w", err)
+	}
+	if len(calldata) < 4 {
+		return errors.New("calldata is shorter than a 4-byte selector")
+	}
+	selector, payload := calldata[:4], calldata[4:]
+
+	var candidates []abi.Method
+	strict := false
+	switch {
+	case methodName != "":
+		method, err := resolveMethod(abiFile, methodName)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(method.ID, selector) {
+			fmt.Printf("Warning: selector %s does not match %s (%s)\n", hexutil.Encode(selector), method.Sig, hexutil.Encode(method.ID))
+		}
+		candidates = append(candidates, method)
+	case abiFile != "":
+		parsed, err := loadABI(abiFile)
+		if err != nil {
+			return err
+		}
+		if method, err := parsed.MethodById(selector); err == nil {
+			candidates = append(candidates, *method)
+		}
+		for _, e := range parsed.Errors {
+			if bytes.Equal(e.ID[:4], selector) {
+				candidates = append(candidates, abi.NewMethod(e.Name, e.Name, abi.Function, "", false, false, e.Inputs, nil))
+			}
+		}
+	default:
+		db, err := c.loadSignatures()
+		if err != nil {
+			return err
+		}
+		candidates = db.LookupFunction(selector)
+		strict = len(candidates) > 1
+	}
+	if len(candidates) == 0 {
+		return fmt.Errorf("unknown selector %s, specify -abi or -method, or import the ABI with abi import", hexutil.Encode(selector))
+	}
+
+	decoded := 0
+	for _, method := range candidates {
+		values, err := method.Inputs.Unpack(payload)
+		if err != nil {
+			if !strict {
+				fmt.Printf("Failed to decode arguments of %s: %v\n", method.Sig, err)
+			}
+			continue
+		}
+		if strict {
+			if packed, err := method.Inputs.Pack(values...); err != nil || !bytes.Equal(packed, payload) {
+				continue
+			}
+		}
+		if decoded > 0 {
+			fmt.Println()
+		}
+		decoded++
+		fmt.Println("Method:  ", signatures.FormatMethod(method.RawName, method.Inputs))
+		fmt.Println("Selector:", hexutil.Encode(selector))
+		printArgs("  ", method.Inputs, values)
+	}
+	if decoded == 0 {
+		return fmt.Errorf("calldata does not match any known signature of selector %s", hexutil.Encode(selector))
+	}
+	if decoded > 1 {
+		fmt.Println("Warning: the selector matches more than one signature, check which one the contract implements")
+	}
+	return nil
+}
+
+// decodeEventLog 按事件定义解码日志。indexed 的静态类型参数从主题中解码，
+// 动态类型（string、bytes、数组、tuple）在主题中只保存哈希，按 bytes32 返回。
+func decodeEventLog(event abi.Event, log *types.Log) ([]interface{}, error) {
+	topics := log.Topics
+	if !event.Anonymous {
+		if len(topics) == 0 || topics[0] != event.ID {
+			return nil, fmt.Errorf("topic 0 does not match %s", event.Sig)
+		}
+		topics = topics[1:]
+	}
+	indexed := 0
+	for _, in := range event.Inputs {
+		if in.Indexed {
+			indexed++
+		}
+	}
+	if indexed != len(topics) {
+		return nil, fmt.Errorf("%s has %d indexed parameter(s) but the log has %d topic(s)", event.Sig, indexed, len(topics))
+	}
+	nonIndexed, err := event.Inputs.NonIndexed().Unpack(log.Data)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, 0, len(event.Inputs))
+	for _, in := range event.Inputs {
+		if !in.Indexed {
+			values = append(values, nonIndexed[0])
+			nonIndexed = nonIndexed[1:]
+			continue
+		}
+		topic := topics[0]
+		topics = topics[1:]
+		switch in.Type.T {
+		case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+			values = append(values, [32]byte(topic))
+		default:
+			v, err := abi.Arguments{{Type: in.Type}}.Unpack(topic.Bytes())
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode topic of %s: %w", in.Name, err)
+			}
+			values = append(values, v[0])
+		}
+	}
+	return values, nil
+}
+
+// abidecodelog 解码事件日志并打印事件签名和参数。日志可以通过主题和数据指定，也可以是某笔交易回执中的所有日志。
+// 参数:
+//
+//	abiFile - 合约 ABI 文件，可以为空。
+//	eventName - 事件名或签名，可以为空；为空时按主题在 ABI 或签名数据库中查找。
+//	topics - 逗号分隔的主题。
+//	data - 十六进制的日志数据。
+//	txHash - 交易哈希，指定时解码交易回执中的所有日志。
+//
+// 返回值:
+//
+//	如果无法获取或解码日志，则返回错误。
+func (c *Client) abidecodelog(abiFile, eventName, topics, data, txHash string) error {
+	var logs []*types.Log
+	if txHash != "" {
+		cli, err := c.dial()
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+		receipt, err := cli.TransactionReceipt(context.Background(), common.HexToHash(txHash))
+		if err != nil {
+			return fmt.Errorf("failed to get receipt of %s: %w", txHash, err)
+		}
+		logs = receipt.Logs
+		if len(logs) == 0 {
+			fmt.Println("Transaction", txHash, "emitted no logs")
+			return nil
+		}
+	} else {
+		log := new(types.Log)
+		for _, t := range strings.Split(topics, ",") {
+			if t = strings.TrimSpace(t); t == "" {
+				continue
+			}
+			b, err := decodeHex(t)
+			if err != nil || len(b) != common.HashLength {
+				return fmt.Errorf("invalid topic %q", t)
+			}
+			log.Topics = append(log.Topics, common.BytesToHash(b))
+		}
+		var err error
+		if log.Data, err = decodeHex(data); err != nil {
+			return fmt.Errorf("invalid log data: %w", err)
+		}
+		logs = append(logs, log)
+	}
+
+	var (
+		parsed   *abi.ABI
+		explicit *abi.Event
+		db       *signatures.DB
+		err      error
+	)
+	if abiFile != "" {
+		if parsed, err = loadABI(abiFile); err != nil {
+			return err
+		}
+	}
+	if eventName != "" {
+		event, err := findEvent(parsed, eventName)
+		if err != nil {
+			return err
+		}
+		explicit = &event
+	} else if parsed == nil {
+		if db, err = c.loadSignatures(); err != nil {
+			return err
+		}
+	}
+
+	failed := 0
+	for i, log := range logs {
+		if i > 0 {
+			fmt.Println()
+		}
+		if txHash != "" {
+			fmt.Printf("Log %d, emitted by %s\n", log.Index, log.Address.Hex())
+		}
+		var candidates []abi.Event
+		switch {
+		case explicit != nil:
+			candidates = append(candidates, *explicit)
+		case len(log.Topics) == 0:
+			// 匿名事件没有主题 0，只能通过 -event 指定。
+		case parsed != nil:
+			if event, err := parsed.EventByID(log.Topics[0]); err == nil {
+				candidates = append(candidates, *event)
+			}
+		default:
+			candidates = db.LookupEvent(log.Topics[0])
+		}
+		decoded := false
+		for _, event := range candidates {
+			values, err := decodeEventLog(event, log)
+			if err != nil {
+				fmt.Printf("Failed to decode %s: %v\n", event.Sig, err)
+				continue
+			}
+			decoded = true
+			fmt.Println("Event:", signatures.FormatEvent(event))
+			printArgs("  ", event.Inputs, values)
+			break
+		}
+		if !decoded {
+			failed++
+			if len(candidates) == 0 {
+				fmt.Println("Unknown event, topics:")
+				for _, t := range log.Topics {
+					fmt.Println("  ", t.Hex())
+				}
+				fmt.Println("Data:", hexutil.Encode(log.Data))
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d log(s) could not be decoded", failed, len(logs))
+	}
+	return nil
+}
+
+// findEvent 按事件名或签名确定事件：指定 ABI 时在 ABI 中查找，否则 name 必须是完整的文本签名。
+func findEvent(parsed *abi.ABI, name string) (abi.Event, error) {
+	if parsed == nil {
+		if !strings.Contains(name, "(") {
+			return abi.Event{}, fmt.Errorf("without -abi, -event must be a full signature like Transfer(address indexed,address indexed,uint256), got %q", name)
+		}
+		return signatures.ParseEvent(name)
+	}
+	name = strings.ReplaceAll(name, " ", "")
+	for _, e := range parsed.Events {
+		if e.Sig == name || e.RawName == name {
+			return e, nil
+		}
+	}
+	return abi.Event{}, fmt.Errorf("event %q not found in ABI", name)
+}
+
+// abiimport 将 ABI 文件中的所有签名，或单个文本签名，加入签名数据库。
+// 参数:
+//
+//	abiFile - 合约 ABI 文件。
+//	sig - 文本签名，与 abiFile 二选一。
+//	event - sig 是否为事件签名。
+//
+// 返回值:
+//
+//	如果解析或保存过程中发生错误，则返回错误。
+func (c *Client) abiimport(abiFile, sig string, event bool) error {
+	db, err := signatures.Load(filepath.Join(c.dataDir, signatures.FileName))
+	if err != nil {
+		return err
+	}
+	added := 0
+	switch {
+	case abiFile != "" && sig != "":
+		return errors.New("specify either -abi or -sig, not both")
+	case abiFile != "":
+		parsed, err := loadABI(abiFile)
+		if err != nil {
+			return err
+		}
+		added = db.AddABI(parsed)
+	case sig != "":
+		var ok bool
+		if event {
+			ok, err = db.AddEvent(sig)
+		} else {
+			ok, err = db.AddFunction(sig)
+		}
+		if err != nil {
+			return err
+		}
+		if ok {
+			added = 1
+		}
+	default:
+		return errors.New("no signatures given, please specify -abi or -sig")
+	}
+	if err := db.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d new signature(s) into %s\n", added, signatures.FileName)
+	return nil
+}
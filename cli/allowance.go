@@ -0,0 +1,252 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_wallet/hdwallet"
+	"go_wallet/registry"
+	"go_wallet/sol"
+	"go_wallet/units"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// parseTokenAmount 按代币精度解析金额，max 或 unlimited 表示无限授权（2^256-1）。
+func parseTokenAmount(value string, decimals uint8) (*big.Int, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "max", "unlimited":
+		return new(big.Int).Set(math.MaxBig256), nil
+	}
+	return units.ParseUnits(value, int(decimals))
+}
+
+// formatTokenAmount 按代币精度格式化金额，无限授权显示为 unlimited。
+func formatTokenAmount(value *big.Int, token registry.Token) string {
+	if value.Cmp(math.MaxBig256) == 0 {
+		return "unlimited " + token.Symbol
+	}
+	return units.FormatUnits(value, int(token.Decimals)) + " " + token.Symbol
+}
+
+// approve 授权 spender 使用 owner 的代币。
+// 授权无限额度，或者将非零授权直接改为另一个非零值（ERC20 approve 竞态问题）时会给出警告。
+// safe 为 true 时，会先将授权重置为 0 并等待确认，再设置新的额度。
+// 参数:
+//
+//	from - 代币持有者，即签名账户。
+//	spender - 被授权地址。
+//	value - 授权额度，按代币单位，max 表示无限授权。
+//	tokenName - 代币符号或合约地址。
+//	safe - 是否使用“先重置再设置”的流程。
+//
+// 返回值:
+//
+//	common.Hash - 设置新额度的交易哈希。
+//	error - 如果授权过程中发生错误，则返回错误。
+func (c *Client) approve(from, spender, value, tokenName string, safe bool) (common.Hash, error) {
+	cli, err := c.dial()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer cli.Close()
+
+	info, err := c.resolveToken(cli, tokenName)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	token, err := sol.NewToken(info.Address, cli)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get token contract: %w", err)
+	}
+	amount, err := parseTokenAmount(value, info.Decimals)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	owner := common.HexToAddress(from)
+	spenderAddr := common.HexToAddress(spender)
+	current, err := token.Allowance(&bind.CallOpts{}, owner, spenderAddr)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get allowance: %w", err)
+	}
+	fmt.Printf("Current allowance of %s for %s: %s\n", spenderAddr.Hex(), owner.Hex(), formatTokenAmount(current, info))
+	fmt.Printf("New allowance: %s\n", formatTokenAmount(amount, info))
+
+	if amount.Cmp(math.MaxBig256) == 0 {
+		fmt.Println("Warning: approving an unlimited amount allows", spenderAddr.Hex(), "to spend all of your", info.Symbol, "now and in the future.")
+		if !confirm("Approve unlimited amount?") {
+			return common.Hash{}, errors.New("approve aborted by user")
+		}
+	}
+	if current.Sign() != 0 && amount.Sign() != 0 && !safe {
+		fmt.Println("Warning: changing a non-zero allowance directly to another non-zero value lets the spender")
+		fmt.Println("front-run the change and spend both the old and the new allowance.")
+		safe = confirm("Reset the allowance to 0 first and then set the new value?")
+		if !safe && !confirm("Change the allowance directly anyway?") {
+			return common.Hash{}, errors.New("approve aborted by user")
+		}
+	}
+
+	parsed, err := sol.TokenMetaData.GetAbi()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	// 需要先重置时，第一笔交易是 approve(spender, 0)，新额度要等重置确认之后再模拟。
+	reset := safe && current.Sign() != 0 && amount.Sign() != 0
+	first := amount
+	if reset {
+		first = big.NewInt(0)
+	}
+	if err := c.simulateContract(context.Background(), cli, owner, info.Address, parsed, nil, "approve", spenderAddr, first); err != nil {
+		return common.Hash{}, err
+	}
+
+	w, err := hdwallet.LoadWallet(from, c.dataDir)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	auth, err := w.HDKeyStore.NewTransactOpts(c.chainID)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if reset {
+		tx, err := token.Approve(auth, spenderAddr, big.NewInt(0))
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("failed to reset allowance: %w", err)
+		}
+		fmt.Println("Reset tx hash:", tx.Hash().Hex())
+		receipt, err := waitForReceipt(context.Background(), cli, tx.Hash(), 1)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			return common.Hash{}, fmt.Errorf("reset transaction %s failed", tx.Hash().Hex())
+		}
+		// 重置确认之后再检查一次，如果在此期间授权额度被使用，提醒用户。
+		spent, err := token.Allowance(&bind.CallOpts{}, owner, spenderAddr)
+		if err == nil && spent.Sign() != 0 {
+			return common.Hash{}, fmt.Errorf("allowance is %s after reset, please check", formatTokenAmount(spent, info))
+		}
+		if err := c.simulateContract(context.Background(), cli, owner, info.Address, parsed, nil, "approve", spenderAddr, amount); err != nil {
+			return common.Hash{}, fmt.Errorf("allowance was reset to 0 but setting the new value would fail: %w", err)
+		}
+	}
+	tx, err := token.Approve(auth, spenderAddr, amount)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+// allowance 查询 owner 授权给 spender 的代币额度。
+// 参数:
+//
+//	owner - 代币持有者。
+//	spender - 被授权地址。
+//	tokenName - 代币符号或合约地址。
+//
+// 返回值:
+//
+//	*big.Int - 授权额度（最小单位）。
+//	error - 如果查询过程中发生错误，则返回错误。
+func (c *Client) allowance(owner, spender, tokenName string) (*big.Int, error) {
+	cli, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+
+	info, err := c.resolveToken(cli, tokenName)
+	if err != nil {
+		return nil, err
+	}
+	token, err := sol.NewToken(info.Address, cli)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token contract: %w", err)
+	}
+	value, err := token.Allowance(&bind.CallOpts{}, common.HexToAddress(owner), common.HexToAddress(spender))
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Allowance of %s for %s: %s\n", spender, owner, formatTokenAmount(value, info))
+	return value, nil
+}
+
+// transferfrom 由 spender 使用授权额度将 from 的代币转给 to。
+// 发送前会检查授权额度和 from 的余额是否足够。
+// 参数:
+//
+//	spender - 被授权地址，即签名账户。
+//	from - 代币持有者。
+//	to - 接收方地址。
+//	value - 转账金额，按代币单位。
+//	tokenName - 代币符号或合约地址。
+//
+// 返回值:
+//
+//	common.Hash - 交易哈希。
+//	error - 如果转账过程中发生错误，则返回错误。
+func (c *Client) transferfrom(spender, from, to, value, tokenName string) (common.Hash, error) {
+	cli, err := c.dial()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer cli.Close()
+
+	info, err := c.resolveToken(cli, tokenName)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	token, err := sol.NewToken(info.Address, cli)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get token contract: %w", err)
+	}
+	amount, err := units.ParseUnits(value, int(info.Decimals))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	owner := common.HexToAddress(from)
+	spenderAddr := common.HexToAddress(spender)
+	allowed, err := token.Allowance(&bind.CallOpts{}, owner, spenderAddr)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get allowance: %w", err)
+	}
+	if allowed.Cmp(amount) < 0 {
+		return common.Hash{}, fmt.Errorf("allowance %s is less than %s", formatTokenAmount(allowed, info), formatTokenAmount(amount, info))
+	}
+	balance, err := token.BalanceOf(&bind.CallOpts{}, owner)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get balance: %w", err)
+	}
+	if balance.Cmp(amount) < 0 {
+		return common.Hash{}, fmt.Errorf("balance of %s is %s, less than %s", owner.Hex(), formatTokenAmount(balance, info), formatTokenAmount(amount, info))
+	}
+
+	c.warnNewRecipient(spenderAddr, common.HexToAddress(to))
+	parsed, err := sol.TokenMetaData.GetAbi()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := c.simulateContract(context.Background(), cli, spenderAddr, info.Address, parsed, nil, "transferFrom", owner, common.HexToAddress(to), amount); err != nil {
+		return common.Hash{}, err
+	}
+
+	w, err := hdwallet.LoadWallet(spender, c.dataDir)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	auth, err := w.HDKeyStore.NewTransactOpts(c.chainID)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	tx, err := token.TransferFrom(auth, owner, common.HexToAddress(to), amount)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
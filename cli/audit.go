@@ -0,0 +1,44 @@
+package client
+
+import (
+	"fmt"
+	"go_wallet/audit"
+	"path/filepath"
+)
+
+// auditverify 校验数据目录下的审计日志是否完整且未被修改。
+// 参数:
+//
+//	expect - 之前保存的某条记录的哈希，不为空时检查日志中仍然包含该记录。
+//	show - 是否打印所有记录。
+//
+// 返回值:
+//
+//	如果无法读取日志或日志被截断、修改，则返回错误。
+func (c *Client) auditverify(expect string, show bool) error {
+	report, err := audit.Verify(c.dataDir, expect)
+	if err != nil {
+		return err
+	}
+	if show {
+		fmt.Printf("%-6s %-30s %-16s %-42s %-8s %-66s %s\n", "SEQ", "TIME (UTC)", "OP", "ACCOUNT", "CHAIN", "DIGEST", "RESULT")
+		for _, e := range report.Entries {
+			fmt.Printf("%-6d %-30s %-16s %-42s %-8d %-66s %s\n", e.Seq, e.Time, e.Op, e.Account.Hex(), e.ChainID, e.Digest.Hex(), e.Result)
+		}
+	}
+	file := filepath.Join(c.dataDir, audit.FileName)
+	if !report.OK() {
+		for _, p := range report.Problems {
+			fmt.Println("  " + p.String())
+		}
+		return fmt.Errorf("%d problem(s) found in %s", len(report.Problems), file)
+	}
+	if len(report.Entries) == 0 {
+		fmt.Printf("Audit log %s is empty, no key has been used with this data directory\n", file)
+		return nil
+	}
+	fmt.Printf("Audit log %s is intact: %d entries\n", file, len(report.Entries))
+	fmt.Println("Last entry hash:", report.Last())
+	fmt.Println("Keep the last hash somewhere else and pass it with -expect next time to detect a rewritten log")
+	return nil
+}
@@ -0,0 +1,331 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"go_wallet/hdwallet"
+	"go_wallet/registry"
+	"go_wallet/sol"
+	"go_wallet/units"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// multicall3Address 是 Multicall3 合约在各条链上的统一部署地址。
+var multicall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+const (
+	defaultBalanceBatch       = 100 // 每个 JSON-RPC 批量请求或 Multicall 调用中的最大调用数
+	defaultBalanceConcurrency = 4   // 同时发送的批量请求数
+)
+
+// balanceResult 是一个地址的余额，第一个为 ETH 余额，之后依次为各代币余额；查询失败的项为 nil。
+type balanceResult []*big.Int
+
+// readAddresses 从文件中读取地址，每行一个，也可以是 CSV 的第一列；空行和 # 开头的行会被忽略。
+func readAddresses(file string) ([]common.Address, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var addrs []common.Address
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		field, _, _ := strings.Cut(text, ",")
+		field = strings.TrimSpace(field)
+		if !common.IsHexAddress(field) {
+			// 允许 CSV 文件带表头。
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("%s:%d: invalid address %q", file, line, field)
+		}
+		addrs = append(addrs, common.HexToAddress(field))
+	}
+	return addrs, scanner.Err()
+}
+
+// balanceCall 是一次余额查询：token 为空地址时查询 ETH 余额。
+type balanceCall struct {
+	account int
+	column  int
+	owner   common.Address
+	token   common.Address
+}
+
+// batchBalances 通过 JSON-RPC 批量请求查询一组余额，所有请求固定在同一个区块上。
+func batchBalances(ctx context.Context, client *rpc.Client, erc20 *abi.ABI, block *big.Int, calls []balanceCall) ([]*big.Int, error) {
+	blockArg := hexutil.EncodeBig(block)
+	elems := make([]rpc.BatchElem, len(calls))
+	results := make([]hexutil.Bytes, len(calls))
+	balances := make([]hexutil.Big, len(calls))
+	for i, call := range calls {
+		if call.token == (common.Address{}) {
+			elems[i] = rpc.BatchElem{Method: "eth_getBalance", Args: []interface{}{call.owner, blockArg}, Result: &balances[i]}
+			continue
+		}
+		data, err := erc20.Pack("balanceOf", call.owner)
+		if err != nil {
+			return nil, err
+		}
+		arg := map[string]interface{}{"to": call.token, "data": hexutil.Bytes(data)}
+		elems[i] = rpc.BatchElem{Method: "eth_call", Args: []interface{}{arg, blockArg}, Result: &results[i]}
+	}
+	if err := client.BatchCallContext(ctx, elems); err != nil {
+		return nil, err
+	}
+	values := make([]*big.Int, len(calls))
+	for i, call := range calls {
+		if elems[i].Error != nil {
+			continue
+		}
+		if call.token == (common.Address{}) {
+			values[i] = balances[i].ToInt()
+			continue
+		}
+		values[i] = unpackBalance(erc20, results[i])
+	}
+	return values, nil
+}
+
+// multicallBalances 通过 Multicall3 的 aggregate3 在一次 eth_call 中查询一组余额，单个调用失败不影响其他调用。
+func multicallBalances(ctx context.Context, cli *ethclient.Client, erc20, mc3 *abi.ABI, block *big.Int, calls []balanceCall) ([]*big.Int, error) {
+	caller, err := sol.NewMulticall3Caller(multicall3Address, cli)
+	if err != nil {
+		return nil, err
+	}
+	batch := make([]sol.Multicall3Call3, len(calls))
+	for i, call := range calls {
+		var (
+			data   []byte
+			target = call.token
+		)
+		if call.token == (common.Address{}) {
+			target = multicall3Address
+			data, err = mc3.Pack("getEthBalance", call.owner)
+		} else {
+			data, err = erc20.Pack("balanceOf", call.owner)
+		}
+		if err != nil {
+			return nil, err
+		}
+		batch[i] = sol.Multicall3Call3{Target: target, AllowFailure: true, CallData: data}
+	}
+	// aggregate3 在 ABI 中是 payable 的，只能通过底层的 Call 以 eth_call 方式调用。
+	var out []interface{}
+	raw := &sol.Multicall3CallerRaw{Contract: caller}
+	if err := raw.Call(&bind.CallOpts{Context: ctx, BlockNumber: block}, &out, "aggregate3", batch); err != nil {
+		return nil, err
+	}
+	results := *abi.ConvertType(out[0], new([]sol.Multicall3Result)).(*[]sol.Multicall3Result)
+	if len(results) != len(calls) {
+		return nil, fmt.Errorf("multicall returned %d results for %d calls", len(results), len(calls))
+	}
+	values := make([]*big.Int, len(calls))
+	for i, r := range results {
+		if r.Success {
+			values[i] = unpackBalance(erc20, r.ReturnData)
+		}
+	}
+	return values, nil
+}
+
+// unpackBalance 解析 balanceOf 或 getEthBalance 的返回值，格式错误时返回 nil。
+func unpackBalance(erc20 *abi.ABI, data []byte) *big.Int {
+	out, err := erc20.Unpack("balanceOf", data)
+	if err != nil || len(out) != 1 {
+		return nil
+	}
+	value, _ := out[0].(*big.Int)
+	return value
+}
+
+// balances 批量查询多个地址的 ETH 和代币余额，打印表格和合计。
+// 查询被拆分为若干批，每批通过一个 JSON-RPC 批量请求或一次 Multicall3 调用完成，并限制同时进行的批数。
+// 所有查询都固定在同一个区块上，保证合计的一致性。
+// 参数:
+//
+//	file - 地址文件，为空时使用数据目录中的所有账户。
+//	tokenNames - 逗号分隔的代币符号或地址，为空时使用默认代币和注册表中的所有代币，none 表示只查询 ETH。
+//	method - auto、batch 或 multicall；auto 在链上部署了 Multicall3 时使用 multicall，否则使用 batch。
+//	batchSize - 每批的最大调用数。
+//	concurrency - 同时进行的批数。
+//
+// 返回值:
+//
+//	如果查询过程中发生错误，则返回错误；单个余额查询失败时在表格中显示为 error。
+func (c *Client) balances(file, tokenNames, method string, batchSize, concurrency int) error {
+	if batchSize < 1 || concurrency < 1 {
+		return errors.New("batch size and concurrency must be at least 1")
+	}
+	var (
+		addrs []common.Address
+		err   error
+	)
+	if file != "" {
+		addrs, err = readAddresses(file)
+	} else {
+		addrs, err = hdwallet.ListAccounts(c.dataDir)
+	}
+	if err != nil {
+		return err
+	}
+	if len(addrs) == 0 {
+		return errors.New("no addresses to query")
+	}
+
+	cli, err := c.dial()
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	var tokens []registry.Token
+	switch strings.ToLower(tokenNames) {
+	case "":
+		if tokens, err = c.trackedTokens(cli); err != nil {
+			return err
+		}
+	case "none":
+	default:
+		for _, name := range strings.Split(tokenNames, ",") {
+			token, err := c.resolveToken(cli, strings.TrimSpace(name))
+			if err != nil {
+				return err
+			}
+			tokens = append(tokens, token)
+		}
+	}
+
+	ctx := context.Background()
+	head, err := cli.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+	block := new(big.Int).SetUint64(head)
+	switch method {
+	case "auto", "multicall":
+		code, err := cli.CodeAt(ctx, multicall3Address, block)
+		if err == nil && len(code) > 0 {
+			method = "multicall"
+		} else if method == "auto" {
+			method = "batch"
+		} else {
+			return fmt.Errorf("no Multicall3 contract at %s on chain %s, use -method batch", multicall3Address.Hex(), c.chainID)
+		}
+	case "batch":
+	default:
+		return fmt.Errorf("unknown method %q, expected auto, batch or multicall", method)
+	}
+	erc20, err := sol.TokenMetaData.GetAbi()
+	if err != nil {
+		return err
+	}
+	mc3, err := sol.Multicall3MetaData.GetAbi()
+	if err != nil {
+		return err
+	}
+
+	// 每个地址一列 ETH 加上每个代币一列。
+	columns := 1 + len(tokens)
+	results := make([]balanceResult, len(addrs))
+	var calls []balanceCall
+	for i, addr := range addrs {
+		results[i] = make(balanceResult, columns)
+		calls = append(calls, balanceCall{account: i, column: 0, owner: addr})
+		for j, t := range tokens {
+			calls = append(calls, balanceCall{account: i, column: j + 1, owner: addr, token: t.Address})
+		}
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		sem      = make(chan struct{}, concurrency)
+	)
+	for start := 0; start < len(calls); start += batchSize {
+		chunk := calls[start:min(start+batchSize, len(calls))]
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			var (
+				values []*big.Int
+				err    error
+			)
+			if method == "multicall" {
+				values, err = multicallBalances(ctx, cli, erc20, mc3, block, chunk)
+			} else {
+				values, err = batchBalances(ctx, cli.Client(), erc20, block, chunk)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			for i, call := range chunk {
+				results[call.account][call.column] = values[i]
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return fmt.Errorf("failed to query balances via %s: %w", method, firstErr)
+	}
+
+	fmt.Printf("Balances of %d address(es) at block %d via %s\n", len(addrs), head, method)
+	symbols := []string{"ETH"}
+	decimals := []int{units.EtherDecimals}
+	for _, t := range tokens {
+		symbols = append(symbols, t.Symbol)
+		decimals = append(decimals, int(t.Decimals))
+	}
+	fmt.Printf("%-42s", "ADDRESS")
+	for _, s := range symbols {
+		fmt.Printf(" %24s", s)
+	}
+	fmt.Println()
+	totals := make([]*big.Int, columns)
+	for j := range totals {
+		totals[j] = new(big.Int)
+	}
+	for i, addr := range addrs {
+		fmt.Printf("%-42s", addr.Hex())
+		for j, value := range results[i] {
+			if value == nil {
+				fmt.Printf(" %24s", "error")
+				continue
+			}
+			totals[j].Add(totals[j], value)
+			fmt.Printf(" %24s", units.FormatUnits(value, decimals[j]))
+		}
+		fmt.Println()
+	}
+	fmt.Printf("%-42s", "TOTAL")
+	for j, total := range totals {
+		fmt.Printf(" %24s", units.FormatUnits(total, decimals[j]))
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,665 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"go_wallet/hdwallet"
+	"go_wallet/registry"
+	"go_wallet/sol"
+	"go_wallet/units"
+	"io"
+	"math/big"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// 批量转账结果的状态。
+const (
+	payoutSigned    = "signed"    // 已签名，尚未确认节点收到
+	payoutSent      = "sent"      // 已广播，等待打包
+	payoutConfirmed = "confirmed" // 已打包且执行成功
+	payoutFailed    = "failed"    // 已打包但执行失败
+	payoutDropped   = "dropped"   // nonce 已被其他交易使用，需要人工核对
+)
+
+const defaultPayoutInterval = time.Second // 两笔转账之间的默认间隔
+
+// payoutResultHeader 是结果文件的表头，与 payoutResult 的字段顺序一致。
+var payoutResultHeader = []string{"row", "to", "asset", "amount", "status", "nonce", "tx_hash", "error", "raw_tx"}
+
+// payout 是付款文件中的一行。
+type payout struct {
+	row   int // 在付款文件中的行号
+	to    common.Address
+	token *registry.Token // 为 nil 时转账 ETH
+	value *big.Int
+}
+
+// asset 返回资产标识：ETH 或代币合约地址。
+func (p payout) asset() string {
+	if p.token == nil {
+		return "ETH"
+	}
+	return p.token.Address.Hex()
+}
+
+// symbol 返回资产符号，用于显示。
+func (p payout) symbol() string {
+	if p.token == nil {
+		return "ETH"
+	}
+	return p.token.Symbol
+}
+
+// amount 返回按资产精度格式化的金额。
+func (p payout) amount() string {
+	if p.token == nil {
+		return units.FormatEther(p.value)
+	}
+	return units.FormatUnits(p.value, int(p.token.Decimals))
+}
+
+// payoutResult 是结果文件中的一行，记录付款文件中某一行的处理状态。
+// 签名后的原始交易在广播之前写入结果文件，重新运行时只会重新广播同一笔交易，不会重复付款。
+type payoutResult struct {
+	Row    int
+	To     common.Address
+	Asset  string
+	Amount string
+	Status string
+	Nonce  uint64
+	TxHash common.Hash
+	Error  string
+	RawTx  string
+}
+
+// readPayouts 读取并校验付款文件。每行的格式为 地址,金额[,代币]，地址也可以是通讯录标签，代币为空或 ETH 时转账以太币，
+// 以太币金额的格式与 transfer 的 -value 相同，代币金额按代币单位。第一行可以是表头，# 开头的行会被忽略。
+// 所有行都会被校验，错误汇总后一起返回。
+func (c *Client) readPayouts(cli *ethclient.Client, file string) ([]payout, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	r := csv.NewReader(bytes.NewReader(content))
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	book, err := c.loadContacts()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		payouts   []payout
+		errs      []error
+		unchecked int
+		tokens    = make(map[string]registry.Token)
+	)
+	for first := true; ; first = false {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := r.FieldPos(0)
+		fail := func(format string, args ...interface{}) {
+			errs = append(errs, fmt.Errorf("%s:%d: %s", file, line, fmt.Sprintf(format, args...)))
+		}
+		for i := range record {
+			record[i] = strings.TrimSpace(record[i])
+		}
+		if _, _, err := lookupAddress(book, record[0]); first && err != nil {
+			continue // 表头
+		}
+		if len(record) < 2 || len(record) > 3 {
+			fail("expected address,amount[,token], got %d field(s)", len(record))
+			continue
+		}
+		p := payout{row: line}
+		to, checksummed, err := lookupAddress(book, record[0])
+		if err != nil {
+			fail("%v", err)
+			continue
+		}
+		if to == (common.Address{}) {
+			fail("refusing to pay the zero address")
+			continue
+		}
+		if !checksummed {
+			unchecked++
+		}
+		p.to = to
+
+		if len(record) == 3 && record[2] != "" && !strings.EqualFold(record[2], "eth") {
+			token, ok := tokens[record[2]]
+			if !ok {
+				if token, err = c.resolveToken(cli, record[2]); err != nil {
+					fail("%v", err)
+					continue
+				}
+				tokens[record[2]] = token
+			}
+			p.token = &token
+			p.value, err = units.ParseUnits(record[1], int(token.Decimals))
+		} else {
+			p.value, err = units.ParseEther(record[1])
+		}
+		if err != nil {
+			fail("invalid amount %q: %v", record[1], err)
+			continue
+		}
+		if p.value.Sign() <= 0 {
+			fail("amount must be positive")
+			continue
+		}
+		payouts = append(payouts, p)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if unchecked > 0 {
+		fmt.Printf("Warning: %d address(es) have no EIP-55 checksum and could not be checked for typos\n", unchecked)
+	}
+	return payouts, nil
+}
+
+// readPayoutResults 读取结果文件，文件不存在时返回空列表。
+func readPayoutResults(file string) ([]*payoutResult, error) {
+	content, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	records, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results file %s: %w", file, err)
+	}
+	var results []*payoutResult
+	for i, record := range records {
+		if i == 0 {
+			continue
+		}
+		if len(record) != len(payoutResultHeader) {
+			return nil, fmt.Errorf("%s:%d: expected %d fields", file, i+1, len(payoutResultHeader))
+		}
+		row, err1 := strconv.Atoi(record[0])
+		nonce, err2 := strconv.ParseUint(record[5], 10, 64)
+		if err := errors.Join(err1, err2); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", file, i+1, err)
+		}
+		results = append(results, &payoutResult{
+			Row:    row,
+			To:     common.HexToAddress(record[1]),
+			Asset:  record[2],
+			Amount: record[3],
+			Status: record[4],
+			Nonce:  nonce,
+			TxHash: common.HexToHash(record[6]),
+			Error:  record[7],
+			RawTx:  record[8],
+		})
+	}
+	return results, nil
+}
+
+// writePayoutResults 按行号顺序写入结果文件。先写入临时文件并同步到磁盘再重命名，
+// 避免进程中断时留下不完整的结果文件。
+func writePayoutResults(file string, results []*payoutResult) error {
+	sort.Slice(results, func(i, j int) bool { return results[i].Row < results[j].Row })
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(payoutResultHeader)
+	for _, r := range results {
+		w.Write([]string{strconv.Itoa(r.Row), r.To.Hex(), r.Asset, r.Amount, r.Status, strconv.FormatUint(r.Nonce, 10), r.TxHash.Hex(), r.Error, r.RawTx})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// checkPayout 根据链上状态更新已签名或已广播的付款，返回是否需要重新广播。
+// 交易已打包时更新为 confirmed 或 failed；仍在交易池中时为 sent；
+// nonce 已被其他交易使用时为 dropped；否则需要重新广播同一笔交易。
+func (c *Client) checkPayout(ctx context.Context, cli *ethclient.Client, r *payoutResult, sender common.Address) (bool, error) {
+	receipt, err := cli.TransactionReceipt(ctx, r.TxHash)
+	if err == nil {
+		r.Status, r.Error = payoutConfirmed, ""
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			r.Status, r.Error = payoutFailed, c.failureReason(ctx, cli, receipt)
+		}
+		return false, nil
+	}
+	if !errors.Is(err, ethereum.NotFound) {
+		return false, err
+	}
+	if _, _, err := cli.TransactionByHash(ctx, r.TxHash); err == nil {
+		r.Status, r.Error = payoutSent, ""
+		return false, nil
+	} else if !errors.Is(err, ethereum.NotFound) {
+		return false, err
+	}
+	nonce, err := cli.NonceAt(ctx, sender, nil)
+	if err != nil {
+		return false, err
+	}
+	if r.Nonce < nonce {
+		r.Status, r.Error = payoutDropped, "nonce used by another transaction, check manually"
+		return false, nil
+	}
+	return true, nil
+}
+
+// broadcastPayout 广播结果中保存的原始交易并更新状态。
+// 广播失败时再次检查链上状态，交易可能已经被节点收到或打包。
+func (c *Client) broadcastPayout(ctx context.Context, cli *ethclient.Client, r *payoutResult, sender common.Address) error {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(common.FromHex(r.RawTx)); err != nil {
+		return fmt.Errorf("row %d: invalid raw transaction: %w", r.Row, err)
+	}
+	sendErr := cli.SendTransaction(ctx, tx)
+	if sendErr == nil {
+		r.Status, r.Error = payoutSent, ""
+		return nil
+	}
+	resend, err := c.checkPayout(ctx, cli, r, sender)
+	if err == nil && !resend {
+		return nil
+	}
+	r.Error = sendErr.Error()
+	return fmt.Errorf("row %d: %w", r.Row, sendErr)
+}
+
+// batchtransfer 按付款文件批量转账 ETH 和代币。
+// 所有行校验通过并检查余额后，显示合计并请求确认，然后使用连续的 nonce 逐笔签名并按间隔广播。
+// 每笔交易签名后、广播前都会写入结果文件；使用同一个结果文件重新运行时，已处理的行会根据链上状态核对，
+// 未被打包的交易会以原来的 nonce 重新广播，因此中断后可以安全地继续，不会重复付款。
+// 参数:
+//
+//	from - 付款账户。
+//	file - 付款文件。
+//	resultsFile - 结果文件，为空时使用付款文件名加 .results.csv 后缀。
+//	interval - 两笔转账之间的间隔。
+//	wait - 是否等待所有交易被打包。
+//	confirmations - 等待的确认数。
+//
+// 返回值:
+//
+//	如果校验、发送过程中发生错误，或者有付款执行失败，则返回错误。
+func (c *Client) batchtransfer(from, file, resultsFile string, interval time.Duration, wait bool, confirmations uint64) error {
+	if !common.IsHexAddress(from) {
+		return fmt.Errorf("invalid from address %q", from)
+	}
+	sender := common.HexToAddress(from)
+	if resultsFile == "" {
+		resultsFile = strings.TrimSuffix(file, filepath.Ext(file)) + ".results.csv"
+	}
+
+	cli, err := c.dial()
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	payouts, err := c.readPayouts(cli, file)
+	if err != nil {
+		return err
+	}
+	if len(payouts) == 0 {
+		return fmt.Errorf("no payments in %s", file)
+	}
+	results, err := readPayoutResults(resultsFile)
+	if err != nil {
+		return err
+	}
+
+	// 结果文件中的每一行都必须与付款文件一致，防止修改付款文件后跳过或重复付款。
+	byRow := make(map[int]payout, len(payouts))
+	for _, p := range payouts {
+		byRow[p.row] = p
+	}
+	processed := make(map[int]bool, len(results))
+	for _, r := range results {
+		p, ok := byRow[r.Row]
+		if !ok || r.To != p.to || r.Asset != p.asset() || r.Amount != p.amount() {
+			return fmt.Errorf("results file %s does not match row %d of %s, use a new results file for a changed payouts file", resultsFile, r.Row, file)
+		}
+		processed[r.Row] = true
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// 核对之前运行中签名的交易。
+	var resend []*payoutResult
+	for _, r := range results {
+		if r.Status != payoutSigned && r.Status != payoutSent {
+			continue
+		}
+		again, err := c.checkPayout(ctx, cli, r, sender)
+		if err != nil {
+			return fmt.Errorf("failed to check row %d: %w", r.Row, err)
+		}
+		if again {
+			resend = append(resend, r)
+		}
+	}
+	sort.Slice(resend, func(i, j int) bool { return resend[i].Nonce < resend[j].Nonce })
+	if len(results) > 0 {
+		if err := writePayoutResults(resultsFile, results); err != nil {
+			return err
+		}
+	}
+	var pending []payout
+	for _, p := range payouts {
+		if !processed[p.row] {
+			pending = append(pending, p)
+		}
+	}
+	if len(resend) == 0 && len(pending) == 0 {
+		fmt.Println("All payments in", file, "have been processed")
+		return c.finishBatch(ctx, cli, resultsFile, results, wait, confirmations)
+	}
+
+	// 估算手续费并检查余额。
+	erc20, err := sol.TokenMetaData.GetAbi()
+	if err != nil {
+		return err
+	}
+	gasPrice, err := cli.SuggestGasPrice(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get gas price: %w", err)
+	}
+	type plan struct {
+		payout
+		to   common.Address
+		data []byte
+		gas  uint64
+	}
+	var (
+		plans  []plan
+		errs   []error
+		fee    = new(big.Int)
+		totals = make(map[string]*big.Int)
+		order  []payout // 每种资产第一次出现的行，用于按顺序显示合计
+	)
+	addTotal := func(p payout) {
+		if totals[p.asset()] == nil {
+			totals[p.asset()] = new(big.Int)
+			order = append(order, p)
+		}
+		totals[p.asset()].Add(totals[p.asset()], p.value)
+	}
+	for _, r := range resend {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(common.FromHex(r.RawTx)); err != nil {
+			return fmt.Errorf("row %d: invalid raw transaction: %w", r.Row, err)
+		}
+		fee.Add(fee, new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(tx.Gas())))
+		addTotal(byRow[r.Row])
+	}
+	for _, p := range pending {
+		pl := plan{payout: p, to: p.to}
+		value := p.value
+		if p.token != nil {
+			pl.to, value = p.token.Address, nil
+			if pl.data, err = erc20.Pack("transfer", p.to, p.value); err != nil {
+				return err
+			}
+		}
+		pl.gas, err = cli.EstimateGas(ctx, ethereum.CallMsg{From: sender, To: &pl.to, Value: value, Data: pl.data})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("row %d: failed to estimate gas: %w", p.row, c.explainRevert(err, erc20)))
+			continue
+		}
+		fee.Add(fee, new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(pl.gas)))
+		plans = append(plans, pl)
+		addTotal(p)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	needEther := new(big.Int).Set(fee)
+	if total := totals["ETH"]; total != nil {
+		needEther.Add(needEther, total)
+	}
+	balance, err := cli.BalanceAt(ctx, sender, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get balance: %w", err)
+	}
+	if balance.Cmp(needEther) < 0 {
+		return fmt.Errorf("insufficient ETH: need %s (including fees), have %s", units.FormatEther(needEther), units.FormatEther(balance))
+	}
+	for _, p := range order {
+		if p.token == nil {
+			continue
+		}
+		token, err := sol.NewToken(p.token.Address, cli)
+		if err != nil {
+			return fmt.Errorf("failed to get token contract: %w", err)
+		}
+		have, err := token.BalanceOf(&bind.CallOpts{Context: ctx}, sender)
+		if err != nil {
+			return fmt.Errorf("failed to get %s balance: %w", p.symbol(), err)
+		}
+		if have.Cmp(totals[p.asset()]) < 0 {
+			return fmt.Errorf("insufficient %s: need %s, have %s", p.symbol(), units.FormatUnits(totals[p.asset()], int(p.token.Decimals)), units.FormatUnits(have, int(p.token.Decimals)))
+		}
+	}
+
+	fmt.Printf("%-6s %-42s %s\n", "ROW", "TO", "AMOUNT")
+	for _, r := range resend {
+		p := byRow[r.Row]
+		fmt.Printf("%-6d %-42s %s %s (resend nonce %d)\n", p.row, p.to.Hex(), p.amount(), p.symbol(), r.Nonce)
+	}
+	known, err := c.knownRecipients(sender)
+	if err != nil {
+		return err
+	}
+	newRecipients := 0
+	for _, pl := range plans {
+		note := ""
+		if !known(pl.payout.to) {
+			note = " (first-time recipient)"
+			newRecipients++
+		}
+		fmt.Printf("%-6d %-42s %s %s%s\n", pl.row, pl.payout.to.Hex(), pl.amount(), pl.symbol(), note)
+	}
+	fmt.Println("Total:")
+	for _, p := range order {
+		sum := payout{token: p.token, value: totals[p.asset()]}
+		fmt.Printf("  %s %s\n", sum.amount(), sum.symbol())
+	}
+	fmt.Printf("Estimated fee: %s ETH at %s gwei\n", units.FormatEther(fee), units.FormatUnits(gasPrice, 9))
+	if newRecipients > 0 {
+		fmt.Printf("Warning: %d row(s) pay addresses not found in contacts or history, double check them\n", newRecipients)
+	}
+	if len(processed) > len(resend) {
+		fmt.Printf("%d row(s) already processed are skipped, see %s\n", len(processed)-len(resend), resultsFile)
+	}
+	if c.simulate {
+		var msgs []ethereum.CallMsg
+		for _, r := range resend {
+			tx := new(types.Transaction)
+			if err := tx.UnmarshalBinary(common.FromHex(r.RawTx)); err != nil {
+				return fmt.Errorf("row %d: invalid raw transaction: %w", r.Row, err)
+			}
+			msg, err := txCallMsg(tx)
+			if err != nil {
+				return fmt.Errorf("row %d: %w", r.Row, err)
+			}
+			msgs = append(msgs, msg)
+		}
+		for _, pl := range plans {
+			msg := ethereum.CallMsg{From: sender, To: &pl.to, Gas: pl.gas, GasPrice: gasPrice, Data: pl.data}
+			if pl.token == nil {
+				msg.Value = pl.value
+			}
+			msgs = append(msgs, msg)
+		}
+		return c.preview(ctx, cli, msgs, erc20)
+	}
+	if !confirm(fmt.Sprintf("Send %d payment(s) from %s?", len(resend)+len(plans), sender.Hex())) {
+		return errors.New("batch transfer aborted by user")
+	}
+
+	// 重新广播已签名的交易不需要解锁账户。
+	var w hdwallet.HDWallet
+	if len(plans) > 0 {
+		if w, err = hdwallet.LoadWallet(from, c.dataDir); err != nil {
+			return err
+		}
+	}
+	save := func() error { return writePayoutResults(resultsFile, results) }
+	sent := 0
+	pace := func() bool {
+		sent++
+		return sent == 1 || sleepContext(ctx, interval)
+	}
+
+	for _, r := range resend {
+		if !pace() {
+			return fmt.Errorf("interrupted, rerun to continue: %w", ctx.Err())
+		}
+		err := c.broadcastPayout(ctx, cli, r, sender)
+		if saveErr := save(); saveErr != nil {
+			return saveErr
+		}
+		if err != nil {
+			return fmt.Errorf("%w, rerun to retry", err)
+		}
+		fmt.Printf("Row %d: resent %s\n", r.Row, r.TxHash.Hex())
+	}
+
+	nonce, err := cli.PendingNonceAt(ctx, sender)
+	if err != nil {
+		return fmt.Errorf("failed to get nonce: %w", err)
+	}
+	for _, pl := range plans {
+		if !pace() {
+			return fmt.Errorf("interrupted, rerun to continue: %w", ctx.Err())
+		}
+		value := pl.value
+		if pl.token != nil {
+			value = new(big.Int)
+		}
+		tx := types.NewTransaction(nonce, pl.to, value, pl.gas, gasPrice, pl.data)
+		signedTx, err := w.HDKeyStore.SignTx(sender, tx, c.chainID)
+		if err != nil {
+			return fmt.Errorf("row %d: failed to sign tx: %w", pl.row, err)
+		}
+		raw, err := signedTx.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		r := &payoutResult{
+			Row:    pl.row,
+			To:     pl.payout.to,
+			Asset:  pl.asset(),
+			Amount: pl.amount(),
+			Status: payoutSigned,
+			Nonce:  nonce,
+			TxHash: signedTx.Hash(),
+			RawTx:  hexutil.Encode(raw),
+		}
+		// 先记录签名后的交易再广播，中断后重新运行只会重新广播这笔交易。
+		results = append(results, r)
+		if err := save(); err != nil {
+			return err
+		}
+		err = c.broadcastPayout(ctx, cli, r, sender)
+		if saveErr := save(); saveErr != nil {
+			return saveErr
+		}
+		if err != nil {
+			return fmt.Errorf("%w, rerun to retry", err)
+		}
+		fmt.Printf("Row %d: sent %s %s to %s, tx %s\n", r.Row, pl.amount(), pl.symbol(), r.To.Hex(), r.TxHash.Hex())
+		nonce++
+	}
+	return c.finishBatch(ctx, cli, resultsFile, results, wait, confirmations)
+}
+
+// failureReason 返回执行失败的付款交易的 revert 原因，用于写入结果文件。
+func (c *Client) failureReason(ctx context.Context, cli *ethclient.Client, receipt *types.Receipt) string {
+	tx, _, err := cli.TransactionByHash(ctx, receipt.TxHash)
+	if err != nil {
+		return "transaction reverted"
+	}
+	reason, err := c.revertReason(ctx, cli, tx, receipt.BlockNumber)
+	if err != nil {
+		return "transaction reverted"
+	}
+	return "transaction reverted: " + reason
+}
+
+// finishBatch 根据需要等待已广播的交易被打包，写入结果文件并打印各状态的数量。
+func (c *Client) finishBatch(ctx context.Context, cli *ethclient.Client, resultsFile string, results []*payoutResult, wait bool, confirmations uint64) error {
+	if wait {
+		for _, r := range results {
+			if r.Status != payoutSent {
+				continue
+			}
+			fmt.Printf("Waiting for row %d (%s) ...\n", r.Row, r.TxHash.Hex())
+			receipt, err := waitForReceipt(ctx, cli, r.TxHash, confirmations)
+			if err != nil {
+				writePayoutResults(resultsFile, results)
+				return err
+			}
+			r.Status, r.Error = payoutConfirmed, ""
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				r.Status, r.Error = payoutFailed, c.failureReason(ctx, cli, receipt)
+			}
+		}
+	}
+	if err := writePayoutResults(resultsFile, results); err != nil {
+		return err
+	}
+	counts := make(map[string]int)
+	for _, r := range results {
+		counts[r.Status]++
+	}
+	fmt.Printf("Results written to %s: %d confirmed, %d sent, %d failed, %d dropped\n",
+		resultsFile, counts[payoutConfirmed], counts[payoutSent], counts[payoutFailed], counts[payoutDropped])
+	if n := counts[payoutFailed] + counts[payoutDropped]; n > 0 {
+		return fmt.Errorf("%d payment(s) failed or were dropped, check %s", n, resultsFile)
+	}
+	return nil
+}
@@ -2,49 +2,140 @@ package client
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"go_wallet/config"
 	"go_wallet/hdwallet"
+	"go_wallet/history"
 	"go_wallet/sol"
+	"go_wallet/units"
 	"log"
 	"math/big"
 	"os"
-	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 type Client struct {
-	network string
-	dataDir string
+	cfg          *config.Config
+	networkName  string
+	network      string
+	dataDir      string
+	chainID      *big.Int       // 配置的链 ID，为 nil 时使用节点返回的链 ID
+	tokenAddress common.Address // token部署合约之后的地址
+	ensRegistry  common.Address // ENS 注册表地址
+	simulate     bool           // 只模拟交易并打印预期结果，不签名和发送
 }
 
-const TokenContractAddress = "0xD47497a911aD47731055BDC68718D2814d88Ff9B" //token部署合约之后的地址
-var chainID = big.NewInt(1234567)
+// NewCmdClient 使用配置中指定名称的网络创建命令行客户端。
+func NewCmdClient(cfg *config.Config, networkName string) (*Client, error) {
+	network, ok := cfg.Networks[networkName]
+	if !ok {
+		return nil, fmt.Errorf("unknown network %q", networkName)
+	}
+	c := &Client{
+		cfg:          cfg,
+		networkName:  networkName,
+		network:      network.RPC,
+		dataDir:      cfg.DataDir,
+		tokenAddress: common.HexToAddress(network.TokenContract),
+		ensRegistry:  defaultENSRegistry,
+	}
+	if network.ENSRegistry != "" {
+		if !common.IsHexAddress(network.ENSRegistry) {
+			return nil, fmt.Errorf("invalid ENS registry address %q", network.ENSRegistry)
+		}
+		c.ensRegistry = common.HexToAddress(network.ENSRegistry)
+	}
+	if network.ChainID != 0 {
+		c.chainID = new(big.Int).SetUint64(network.ChainID)
+	}
+	return c, nil
+}
 
-func NewCmdClient(network, dataDir string) *Client {
-	return &Client{
-		network: network,
-		dataDir: dataDir,
+// dial 连接到配置的以太坊节点，并通过 eth_chainId 检查节点的链 ID 与配置是否一致，
+// 防止交易在其他链上被重放。配置中没有链 ID 时使用节点返回的链 ID。
+func (c *Client) dial() (*ethclient.Client, error) {
+	return c.dialURL(c.network)
+}
+
+// dialURL 连接到指定地址的以太坊节点（例如 WebSocket 地址），并同样检查链 ID。
+func (c *Client) dialURL(url string) (*ethclient.Client, error) {
+	cli, err := ethclient.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the Ethereum client: %w", err)
+	}
+	id, err := cli.ChainID(context.Background())
+	if err != nil {
+		cli.Close()
+		return nil, fmt.Errorf("failed to get chain id from %s: %w", url, err)
 	}
+	if c.chainID == nil {
+		c.chainID = id
+	} else if id.Cmp(c.chainID) != 0 {
+		cli.Close()
+		return nil, fmt.Errorf("chain id mismatch: node %s reports %s, network %q expects %s", url, id, c.networkName, c.chainID)
+	}
+	return cli, nil
 }
 
 func (c *Client) Help() {
+	fmt.Println("./go_wallet [-config FILE] [-network NAME] [-rpc URL] [-chainid ID] [-tokencontract ADDR] [-ensregistry ADDR] [-datadir DIR] COMMAND ...")
+	fmt.Println("  global flags can also be set by GO_WALLET_CONFIG, GO_WALLET_NETWORK, GO_WALLET_RPC, GO_WALLET_CHAIN_ID, GO_WALLET_TOKEN, GO_WALLET_ENS_REGISTRY, GO_WALLET_DATADIR")
 	fmt.Println("./go_wallet createwallet -pass PASSWORD --for create new wallet")
-	fmt.Println("./go_wallet transfer -from FROM_ADDRESS -toaddr TO_ADDRESS -value VALUE --for transfer from acct to toaddr")
+	fmt.Println("./go_wallet transfer -from FROM_ADDRESS -toaddr TO_ADDRESS -value VALUE [-data HEX|-memo TEXT] --for transfer from acct to toaddr, VALUE like 1.5ether, 20gwei or 0.001")
+	fmt.Println("./go_wallet batchtransfer -from FROM -file FILE [-results FILE] [-interval 1s] --for pay ETH and tokens to every row of a CSV file (address,amount[,token])")
 	fmt.Println("./go_wallet balance -from FROM --for get balance of acct")
-	fmt.Println("./go_wallet sendtoken -from FROM -toaddr TOADDR -value VALUE --for sendtoken")
-	fmt.Println("./go_wallet tokenbalance -from FROM --for get token balance of acct")
-	fmt.Println("./go_wallet detail -who WHO --for get tokendetail")
+	fmt.Println("./go_wallet sendtoken -from FROM -toaddr TOADDR -value VALUE [-token SYMBOL|ADDRESS] --for sendtoken, VALUE in token units like 12.5")
+	fmt.Println("./go_wallet tokenbalance -from FROM [-token SYMBOL|ADDRESS] --for get token balance of acct")
+	fmt.Println("./go_wallet detail -who WHO [-token SYMBOL|ADDRESS] [-from-block N] [-to-block N] --for get token transfer history")
+	fmt.Println("./go_wallet addtoken -address ADDRESS [-symbol SYMBOL] --for add a token to the registry of current chain")
+	fmt.Println("./go_wallet removetoken -token SYMBOL|ADDRESS --for remove a token from the registry")
+	fmt.Println("./go_wallet tokens --for list registered tokens of current chain")
+	fmt.Println("./go_wallet deploytoken -from FROM -symbol SYMBOL --for deploy a new token contract and save it to the config")
+	fmt.Println("./go_wallet mint -from OWNER -to TO -value VALUE [-token SYMBOL|ADDRESS] --for mint tokens, only the deployer can mint")
+	fmt.Println("./go_wallet approve -from OWNER -spender SPENDER -value VALUE|max [-token SYMBOL|ADDRESS] [-safe] --for approve spender to use tokens")
+	fmt.Println("./go_wallet allowance -owner OWNER -spender SPENDER [-token SYMBOL|ADDRESS] --for get allowance of spender")
+	fmt.Println("./go_wallet transferfrom -spender SPENDER -from OWNER -toaddr TOADDR -value VALUE [-token SYMBOL|ADDRESS] --for transfer tokens with allowance")
+	fmt.Println("./go_wallet watch [-account ADDR,...] [-token SYMBOL|ADDRESS] [-ws URL] [-from-block N] [-interval 5s] --for stream Transfer and Approval events as JSON lines")
+	fmt.Println("./go_wallet history [-account ADDR] [-asset eth|SYMBOL|ADDRESS] [-direction in|out] [-from DATE] [-to DATE] [-index-from N] [-offline] --for index and query local transaction history")
+	fmt.Println("./go_wallet export -account ADDR [-from DATE] [-to DATE] [-format csv|json] [-asset eth|SYMBOL|ADDRESS] [-out FILE] --for export account statement")
+	fmt.Println("./go_wallet balances [-file FILE] [-tokens SYMBOLS|none] [-method auto|batch|multicall] [-batch N] [-concurrency N] --for bulk query balances")
+	fmt.Println("./go_wallet call -contract ADDR -abi FILE -method METHOD [-from ADDR] [-block N] [ARGS...] --for call a contract method read-only and decode the result")
+	fmt.Println("./go_wallet send -from FROM -contract ADDR -abi FILE -method METHOD [-value VALUE] [ARGS...] --for send a transaction calling a contract method")
+	fmt.Println("./go_wallet abi encode [-abi FILE] -method METHOD|SIGNATURE [ARGS...] --for encode calldata")
+	fmt.Println("./go_wallet abi decode [-abi FILE] [-method METHOD|SIGNATURE] -data HEX --for decode calldata, looking up the selector in the signature database")
+	fmt.Println("./go_wallet abi decodelog [-abi FILE] [-event EVENT|SIGNATURE] -topics T0,T1,... [-data HEX] | -tx HASH --for decode event logs")
+	fmt.Println("./go_wallet abi import -abi FILE | -sig SIGNATURE [-event] --for add signatures to the local signature database")
+	fmt.Println("./go_wallet contacts add -label LABEL -address ADDR [-note TEXT] --for add an address to the address book")
+	fmt.Println("./go_wallet contacts list --for list the address book")
+	fmt.Println("./go_wallet contacts remove -label LABEL --for remove an address from the address book")
+	fmt.Println("./go_wallet policy -account ADDR --for show the spending policy of an account and what it signed in the last 24h")
+	fmt.Println("./go_wallet auditverify [-expect HASH] [-show] --for verify the hash-chained audit log of key usage and signatures")
+	fmt.Println("./go_wallet serve [-socket PATH] [-http 127.0.0.1:PORT] [-rules FILE] --for run a clef-compatible signer for geth --signer, approving every request")
+	fmt.Println("./go_wallet txstatus -tx HASH --for get status and receipt of a transaction")
+	fmt.Println("./go_wallet buildtx -from FROM -toaddr TOADDR -value VALUE [-data HEX|-memo TEXT] [-format json|rlp] [-out FILE] --for build an unsigned tx online")
+	fmt.Println("./go_wallet signtx -in FILE [-from FROM] [-out FILE] --for sign an unsigned tx offline")
+	fmt.Println("./go_wallet broadcast -in FILE|-raw HEX --for broadcast a signed tx")
+	fmt.Println("./go_wallet decodetx -in FILE|-raw HEX --for decode and inspect a raw transaction")
+	fmt.Println("./go_wallet signmessage -from FROM -msg TEXT|-file FILE --for sign a message (EIP-191 personal_sign)")
+	fmt.Println("./go_wallet verifymessage -msg TEXT|-file FILE -sig SIG [-address ADDR] --for recover the signer of a message")
+	fmt.Println("./go_wallet signtypeddata -from FROM -file FILE --for sign EIP-712 typed data")
+	fmt.Println("./go_wallet verifytypeddata -file FILE -sig SIG [-address ADDR] --for recover the signer of EIP-712 typed data")
+	fmt.Println("  METHOD is a name or a full signature like transfer(address,uint256); array and tuple ARGS are JSON arrays")
+	fmt.Println("  transfer, sendtoken, batchtransfer, send, mint, approve and transferfrom accept -wait [-confirmations N] to wait for the receipt")
+	fmt.Println("  every sending command, including deploytoken and broadcast, accepts -simulate to preview gas and balance changes without sending")
+	fmt.Println("  address flags take a checksummed hex address, a contact LABEL or an ENS NAME like vitalik.eth; mixed-case addresses with a bad EIP-55 checksum are rejected")
 }
 
-func (c Client) Run() {
-	if len(os.Args) < 2 {
+func (c *Client) Run(args []string) {
+	if len(args) < 1 {
 		c.Help()
 		os.Exit(1)
 	}
@@ -54,65 +145,507 @@ func (c Client) Run() {
 
 	// transfer
 	transfer_cmd := flag.NewFlagSet("transfer", flag.ExitOnError)
-	transfer_cmd_from := transfer_cmd.String("from", "", "FROM ADDRESS")
-	transfer_cmd_toaddr := transfer_cmd.String("toaddr", "", "TO ADDRESS")
-	transfer_cmd_value := transfer_cmd.Int64("value", 0, "VALUE")
+	transfer_cmd_from := c.addressVar(transfer_cmd, "from", "FROM ADDRESS")
+	transfer_cmd_toaddr := c.addressVar(transfer_cmd, "toaddr", "TO ADDRESS")
+	transfer_cmd_value := transfer_cmd.String("value", "0", "VALUE, e.g. 1.5ether, 20gwei, 0.001 (ether)")
+	transfer_cmd_wait := transfer_cmd.Bool("wait", false, "wait for the transaction receipt")
+	transfer_cmd_confirmations := transfer_cmd.Uint64("confirmations", 1, "CONFIRMATIONS to wait for")
+	transfer_cmd_simulate := transfer_cmd.Bool("simulate", false, "simulate the transaction and preview balance changes without sending")
+	transfer_cmd_data := transfer_cmd.String("data", "", "optional hex DATA attached to the transaction")
+	transfer_cmd_memo := transfer_cmd.String("memo", "", "optional UTF-8 MEMO attached to the transaction")
+
+	// batchtransfer
+	batchtransfer_cmd := flag.NewFlagSet("batchtransfer", flag.ExitOnError)
+	batchtransfer_cmd_from := c.addressVar(batchtransfer_cmd, "from", "FROM ADDRESS")
+	batchtransfer_cmd_file := batchtransfer_cmd.String("file", "", "payouts FILE, one address,amount[,token] per line")
+	batchtransfer_cmd_results := batchtransfer_cmd.String("results", "", "results FILE, FILE.results.csv if empty")
+	batchtransfer_cmd_interval := batchtransfer_cmd.Duration("interval", defaultPayoutInterval, "INTERVAL between transactions")
+	batchtransfer_cmd_wait := batchtransfer_cmd.Bool("wait", false, "wait for all transaction receipts")
+	batchtransfer_cmd_confirmations := batchtransfer_cmd.Uint64("confirmations", 1, "CONFIRMATIONS to wait for")
+	batchtransfer_cmd_simulate := batchtransfer_cmd.Bool("simulate", false, "simulate the transactions and preview balance changes without sending")
 
 	// balance
 	balance_cmd := flag.NewFlagSet("balance", flag.ExitOnError)
-	balance_cmd_from := balance_cmd.String("from", "", "FROM")
+	balance_cmd_from := c.addressVar(balance_cmd, "from", "FROM")
 
 	// sendtoken
 	sendtoken_cmd := flag.NewFlagSet("sendtoken", flag.ExitOnError)
-	sendtoken_cmd_from := sendtoken_cmd.String("from", "", "FROM")
-	sendtoken_cmd_toaddr := sendtoken_cmd.String("toaddr", "", "TOADDR")
-	sendtoken_cmd_value := sendtoken_cmd.Int64("value", 0, "VALUE")
+	sendtoken_cmd_from := c.addressVar(sendtoken_cmd, "from", "FROM")
+	sendtoken_cmd_toaddr := c.addressVar(sendtoken_cmd, "toaddr", "TOADDR")
+	sendtoken_cmd_value := sendtoken_cmd.String("value", "0", "VALUE in token units, e.g. 12.5")
+	sendtoken_cmd_token := sendtoken_cmd.String("token", "", "token SYMBOL or ADDRESS, default token contract if empty")
+	sendtoken_cmd_wait := sendtoken_cmd.Bool("wait", false, "wait for the transaction receipt")
+	sendtoken_cmd_confirmations := sendtoken_cmd.Uint64("confirmations", 1, "CONFIRMATIONS to wait for")
+	sendtoken_cmd_simulate := sendtoken_cmd.Bool("simulate", false, "simulate the transaction and preview balance changes without sending")
 
 	// tokenbalance
 	tokenbalance_cmd := flag.NewFlagSet("tokenbalance", flag.ExitOnError)
-	tokenbalance_cmd_from := tokenbalance_cmd.String("from", "", "FROM")
+	tokenbalance_cmd_from := c.addressVar(tokenbalance_cmd, "from", "FROM")
+	tokenbalance_cmd_token := tokenbalance_cmd.String("token", "", "token SYMBOL or ADDRESS, default token contract if empty")
 
 	// detail
 	detail_cmd := flag.NewFlagSet("detail", flag.ExitOnError)
-	detail_cmd_who := detail_cmd.String("who", "", "WHO")
+	detail_cmd_who := c.addressVar(detail_cmd, "who", "WHO")
+	detail_cmd_token := detail_cmd.String("token", "", "token SYMBOL or ADDRESS, default token contract if empty")
+	detail_cmd_from_block := detail_cmd.Uint64("from-block", 0, "FROM BLOCK to scan from")
+	detail_cmd_to_block := detail_cmd.Uint64("to-block", 0, "TO BLOCK to scan to, latest block if 0")
+
+	// policy
+	policy_cmd := flag.NewFlagSet("policy", flag.ExitOnError)
+	policy_cmd_account := c.addressVar(policy_cmd, "account", "ACCOUNT")
+
+	// auditverify
+	auditverify_cmd := flag.NewFlagSet("auditverify", flag.ExitOnError)
+	auditverify_cmd_expect := auditverify_cmd.String("expect", "", "HASH of an entry saved earlier, which must still be in the log")
+	auditverify_cmd_show := auditverify_cmd.Bool("show", false, "print all entries")
+
+	// serve
+	serve_cmd := flag.NewFlagSet("serve", flag.ExitOnError)
+	serve_cmd_socket := serve_cmd.String("socket", "", "unix SOCKET path, default signer.ipc in the data directory when -http is not set")
+	serve_cmd_http := serve_cmd.String("http", "", "loopback HTTP address, e.g. 127.0.0.1:8550")
+	serve_cmd_rules := serve_cmd.String("rules", "", "rules FILE approving requests automatically, every request is confirmed on the terminal if empty")
+
+	// txstatus
+	txstatus_cmd := flag.NewFlagSet("txstatus", flag.ExitOnError)
+	txstatus_cmd_tx := txstatus_cmd.String("tx", "", "TX HASH")
+
+	// buildtx
+	buildtx_cmd := flag.NewFlagSet("buildtx", flag.ExitOnError)
+	buildtx_cmd_from := c.addressVar(buildtx_cmd, "from", "FROM ADDRESS")
+	buildtx_cmd_toaddr := c.addressVar(buildtx_cmd, "toaddr", "TO ADDRESS")
+	buildtx_cmd_value := buildtx_cmd.String("value", "0", "VALUE, e.g. 1.5ether, 20gwei, 0.001 (ether)")
+	buildtx_cmd_format := buildtx_cmd.String("format", "json", "output FORMAT, json or rlp")
+	buildtx_cmd_out := buildtx_cmd.String("out", "", "output FILE, stdout if empty")
+	buildtx_cmd_data := buildtx_cmd.String("data", "", "optional hex DATA attached to the transaction")
+	buildtx_cmd_memo := buildtx_cmd.String("memo", "", "optional UTF-8 MEMO attached to the transaction")
+
+	// signtx
+	signtx_cmd := flag.NewFlagSet("signtx", flag.ExitOnError)
+	signtx_cmd_from := c.addressVar(signtx_cmd, "from", "FROM ADDRESS, required for rlp input")
+	signtx_cmd_in := signtx_cmd.String("in", "", "unsigned tx FILE")
+	signtx_cmd_out := signtx_cmd.String("out", "", "output FILE, stdout if empty")
+
+	// broadcast
+	broadcast_cmd := flag.NewFlagSet("broadcast", flag.ExitOnError)
+	broadcast_cmd_in := broadcast_cmd.String("in", "", "signed tx FILE")
+	broadcast_cmd_raw := broadcast_cmd.String("raw", "", "signed tx HEX")
+	broadcast_cmd_wait := broadcast_cmd.Bool("wait", false, "wait for the transaction receipt")
+	broadcast_cmd_confirmations := broadcast_cmd.Uint64("confirmations", 1, "CONFIRMATIONS to wait for")
+	broadcast_cmd_simulate := broadcast_cmd.Bool("simulate", false, "simulate the transaction and preview balance changes without sending")
+
+	// decodetx
+	decodetx_cmd := flag.NewFlagSet("decodetx", flag.ExitOnError)
+	decodetx_cmd_in := decodetx_cmd.String("in", "", "raw tx FILE")
+	decodetx_cmd_raw := decodetx_cmd.String("raw", "", "raw tx HEX")
+
+	// signmessage
+	signmessage_cmd := flag.NewFlagSet("signmessage", flag.ExitOnError)
+	signmessage_cmd_from := c.addressVar(signmessage_cmd, "from", "FROM ADDRESS")
+	signmessage_cmd_msg := signmessage_cmd.String("msg", "", "message TEXT")
+	signmessage_cmd_file := signmessage_cmd.String("file", "", "message FILE")
+
+	// verifymessage
+	verifymessage_cmd := flag.NewFlagSet("verifymessage", flag.ExitOnError)
+	verifymessage_cmd_address := c.addressVar(verifymessage_cmd, "address", "expected signer ADDRESS")
+	verifymessage_cmd_msg := verifymessage_cmd.String("msg", "", "message TEXT")
+	verifymessage_cmd_file := verifymessage_cmd.String("file", "", "message FILE")
+	verifymessage_cmd_sig := verifymessage_cmd.String("sig", "", "SIGNATURE HEX")
+
+	// signtypeddata
+	signtypeddata_cmd := flag.NewFlagSet("signtypeddata", flag.ExitOnError)
+	signtypeddata_cmd_from := c.addressVar(signtypeddata_cmd, "from", "FROM ADDRESS")
+	signtypeddata_cmd_file := signtypeddata_cmd.String("file", "", "typed data json FILE")
+
+	// verifytypeddata
+	verifytypeddata_cmd := flag.NewFlagSet("verifytypeddata", flag.ExitOnError)
+	verifytypeddata_cmd_address := c.addressVar(verifytypeddata_cmd, "address", "expected signer ADDRESS")
+	verifytypeddata_cmd_file := verifytypeddata_cmd.String("file", "", "typed data json FILE")
+	verifytypeddata_cmd_sig := verifytypeddata_cmd.String("sig", "", "SIGNATURE HEX")
+
+	// addtoken
+	addtoken_cmd := flag.NewFlagSet("addtoken", flag.ExitOnError)
+	addtoken_cmd_address := c.addressVar(addtoken_cmd, "address", "token contract ADDRESS")
+	addtoken_cmd_symbol := addtoken_cmd.String("symbol", "", "override token SYMBOL")
+
+	// removetoken
+	removetoken_cmd := flag.NewFlagSet("removetoken", flag.ExitOnError)
+	removetoken_cmd_token := removetoken_cmd.String("token", "", "token SYMBOL or ADDRESS")
+
+	// tokens
+	tokens_cmd := flag.NewFlagSet("tokens", flag.ExitOnError)
+
+	// history
+	history_cmd := flag.NewFlagSet("history", flag.ExitOnError)
+	history_cmd_account := c.addressVar(history_cmd, "account", "ACCOUNT ADDRESS, all accounts in the keystore if empty")
+	history_cmd_asset := history_cmd.String("asset", "", "eth, token SYMBOL or ADDRESS, all assets if empty")
+	history_cmd_direction := history_cmd.String("direction", "", "in or out, relative to -account")
+	history_cmd_from := history_cmd.String("from", "", "FROM DATE, YYYY-MM-DD or RFC3339")
+	history_cmd_to := history_cmd.String("to", "", "TO DATE (inclusive), YYYY-MM-DD or RFC3339")
+	history_cmd_index_from := history_cmd.Int64("index-from", -1, "BLOCK to start indexing from when the index is created or rebuilt, 0 for genesis, the last 50000 blocks if negative")
+	history_cmd_offline := history_cmd.Bool("offline", false, "query the local index without syncing")
+
+	// export
+	export_cmd := flag.NewFlagSet("export", flag.ExitOnError)
+	export_cmd_account := c.addressVar(export_cmd, "account", "ACCOUNT ADDRESS")
+	export_cmd_from := export_cmd.String("from", "", "FROM DATE, YYYY-MM-DD or RFC3339")
+	export_cmd_to := export_cmd.String("to", "", "TO DATE (inclusive), YYYY-MM-DD or RFC3339")
+	export_cmd_format := export_cmd.String("format", "csv", "output FORMAT, csv or json")
+	export_cmd_asset := export_cmd.String("asset", "", "eth, token SYMBOL or ADDRESS, all assets if empty")
+	export_cmd_out := export_cmd.String("out", "", "output FILE, stdout if empty")
+
+	// call
+	call_cmd := flag.NewFlagSet("call", flag.ExitOnError)
+	call_cmd_contract := c.addressVar(call_cmd, "contract", "CONTRACT ADDRESS")
+	call_cmd_abi := call_cmd.String("abi", "", "ABI FILE, an ABI array or a compiler artifact")
+	call_cmd_method := call_cmd.String("method", "", "METHOD name or signature, e.g. balanceOf(address)")
+	call_cmd_from := c.addressVar(call_cmd, "from", "optional FROM ADDRESS of the call")
+	call_cmd_block := call_cmd.Uint64("block", 0, "BLOCK number to call at, latest if 0")
+
+	// send
+	send_cmd := flag.NewFlagSet("send", flag.ExitOnError)
+	send_cmd_from := c.addressVar(send_cmd, "from", "FROM ADDRESS")
+	send_cmd_contract := c.addressVar(send_cmd, "contract", "CONTRACT ADDRESS")
+	send_cmd_abi := send_cmd.String("abi", "", "ABI FILE, an ABI array or a compiler artifact")
+	send_cmd_method := send_cmd.String("method", "", "METHOD name or signature, e.g. transfer(address,uint256)")
+	send_cmd_value := send_cmd.String("value", "0", "VALUE of ETH to send to a payable method, e.g. 1.5ether")
+	send_cmd_wait := send_cmd.Bool("wait", false, "wait for the transaction receipt")
+	send_cmd_confirmations := send_cmd.Uint64("confirmations", 1, "CONFIRMATIONS to wait for")
+	send_cmd_simulate := send_cmd.Bool("simulate", false, "simulate the transaction and preview balance changes without sending")
+
+	// abi encode
+	abiencode_cmd := flag.NewFlagSet("abi encode", flag.ExitOnError)
+	abiencode_cmd_abi := abiencode_cmd.String("abi", "", "optional ABI FILE")
+	abiencode_cmd_method := abiencode_cmd.String("method", "", "METHOD name, or a full SIGNATURE without -abi")
+
+	// abi decode
+	abidecode_cmd := flag.NewFlagSet("abi decode", flag.ExitOnError)
+	abidecode_cmd_abi := abidecode_cmd.String("abi", "", "optional ABI FILE")
+	abidecode_cmd_method := abidecode_cmd.String("method", "", "optional METHOD name or SIGNATURE, looked up by selector if empty")
+	abidecode_cmd_data := abidecode_cmd.String("data", "", "calldata HEX")
+
+	// abi decodelog
+	abidecodelog_cmd := flag.NewFlagSet("abi decodelog", flag.ExitOnError)
+	abidecodelog_cmd_abi := abidecodelog_cmd.String("abi", "", "optional ABI FILE")
+	abidecodelog_cmd_event := abidecodelog_cmd.String("event", "", "optional EVENT name or SIGNATURE, looked up by topic 0 if empty")
+	abidecodelog_cmd_topics := abidecodelog_cmd.String("topics", "", "comma separated TOPICS of the log")
+	abidecodelog_cmd_data := abidecodelog_cmd.String("data", "", "DATA HEX of the log")
+	abidecodelog_cmd_tx := abidecodelog_cmd.String("tx", "", "decode all logs in the receipt of transaction HASH")
+
+	// abi import
+	abiimport_cmd := flag.NewFlagSet("abi import", flag.ExitOnError)
+	abiimport_cmd_abi := abiimport_cmd.String("abi", "", "ABI FILE to import all signatures from")
+	abiimport_cmd_sig := abiimport_cmd.String("sig", "", "a single text SIGNATURE, e.g. transfer(address to,uint256 amount)")
+	abiimport_cmd_event := abiimport_cmd.Bool("event", false, "-sig is an event signature")
+
+	// contacts add
+	contactsadd_cmd := flag.NewFlagSet("contacts add", flag.ExitOnError)
+	contactsadd_cmd_label := contactsadd_cmd.String("label", "", "LABEL of the contact, letters, digits, _ or -")
+	contactsadd_cmd_address := contactsadd_cmd.String("address", "", "ADDRESS of the contact, EIP-55 checksummed if mixed case")
+	contactsadd_cmd_note := contactsadd_cmd.String("note", "", "optional NOTE")
+
+	// contacts list
+	contactslist_cmd := flag.NewFlagSet("contacts list", flag.ExitOnError)
+
+	// contacts remove
+	contactsremove_cmd := flag.NewFlagSet("contacts remove", flag.ExitOnError)
+	contactsremove_cmd_label := contactsremove_cmd.String("label", "", "LABEL of the contact to remove")
+
+	// balances
+	balances_cmd := flag.NewFlagSet("balances", flag.ExitOnError)
+	balances_cmd_file := balances_cmd.String("file", "", "FILE with one address per line, all accounts in the keystore if empty")
+	balances_cmd_tokens := balances_cmd.String("tokens", "", "comma separated token SYMBOLS or ADDRESSES, none for ETH only, all known tokens if empty")
+	balances_cmd_method := balances_cmd.String("method", "auto", "query METHOD, auto, batch or multicall")
+	balances_cmd_batch := balances_cmd.Int("batch", defaultBalanceBatch, "max calls per batch request or multicall")
+	balances_cmd_concurrency := balances_cmd.Int("concurrency", defaultBalanceConcurrency, "max concurrent requests")
+
+	// watch
+	watch_cmd := flag.NewFlagSet("watch", flag.ExitOnError)
+	watch_cmd_account := c.addressListVar(watch_cmd, "account", "comma separated ACCOUNTS to watch, all accounts in the keystore if empty")
+	watch_cmd_token := watch_cmd.String("token", "", "token SYMBOL or ADDRESS, default token contract if empty")
+	watch_cmd_ws := watch_cmd.String("ws", "", "WebSocket URL for subscriptions, poll over HTTP if empty")
+	watch_cmd_from_block := watch_cmd.Uint64("from-block", 0, "FROM BLOCK to start from, latest block if 0")
+	watch_cmd_interval := watch_cmd.Duration("interval", watchInterval, "polling INTERVAL over HTTP")
+
+	// deploytoken
+	deploytoken_cmd := flag.NewFlagSet("deploytoken", flag.ExitOnError)
+	deploytoken_cmd_from := c.addressVar(deploytoken_cmd, "from", "FROM ADDRESS, the owner of the new token")
+	deploytoken_cmd_symbol := deploytoken_cmd.String("symbol", "", "token SYMBOL")
+	deploytoken_cmd_simulate := deploytoken_cmd.Bool("simulate", false, "simulate the deployment without sending")
+
+	// mint
+	mint_cmd := flag.NewFlagSet("mint", flag.ExitOnError)
+	mint_cmd_from := c.addressVar(mint_cmd, "from", "OWNER ADDRESS of the token contract")
+	mint_cmd_to := c.addressVar(mint_cmd, "to", "TO ADDRESS")
+	mint_cmd_value := mint_cmd.String("value", "0", "VALUE in token units")
+	mint_cmd_token := mint_cmd.String("token", "", "token SYMBOL or ADDRESS, default token contract if empty")
+	mint_cmd_wait := mint_cmd.Bool("wait", false, "wait for the transaction receipt")
+	mint_cmd_confirmations := mint_cmd.Uint64("confirmations", 1, "CONFIRMATIONS to wait for")
+	mint_cmd_simulate := mint_cmd.Bool("simulate", false, "simulate the transaction and preview balance changes without sending")
+
+	// approve
+	approve_cmd := flag.NewFlagSet("approve", flag.ExitOnError)
+	approve_cmd_from := c.addressVar(approve_cmd, "from", "OWNER ADDRESS")
+	approve_cmd_spender := c.addressVar(approve_cmd, "spender", "SPENDER ADDRESS")
+	approve_cmd_value := approve_cmd.String("value", "0", "VALUE in token units, or max for unlimited")
+	approve_cmd_token := approve_cmd.String("token", "", "token SYMBOL or ADDRESS, default token contract if empty")
+	approve_cmd_safe := approve_cmd.Bool("safe", false, "reset the allowance to 0 before setting a new non-zero value")
+	approve_cmd_wait := approve_cmd.Bool("wait", false, "wait for the transaction receipt")
+	approve_cmd_confirmations := approve_cmd.Uint64("confirmations", 1, "CONFIRMATIONS to wait for")
+	approve_cmd_simulate := approve_cmd.Bool("simulate", false, "simulate the transaction and preview balance changes without sending")
 
-	switch os.Args[1] {
+	// allowance
+	allowance_cmd := flag.NewFlagSet("allowance", flag.ExitOnError)
+	allowance_cmd_owner := c.addressVar(allowance_cmd, "owner", "OWNER ADDRESS")
+	allowance_cmd_spender := c.addressVar(allowance_cmd, "spender", "SPENDER ADDRESS")
+	allowance_cmd_token := allowance_cmd.String("token", "", "token SYMBOL or ADDRESS, default token contract if empty")
+
+	// transferfrom
+	transferfrom_cmd := flag.NewFlagSet("transferfrom", flag.ExitOnError)
+	transferfrom_cmd_spender := c.addressVar(transferfrom_cmd, "spender", "SPENDER ADDRESS, the signing account")
+	transferfrom_cmd_from := c.addressVar(transferfrom_cmd, "from", "OWNER ADDRESS of the tokens")
+	transferfrom_cmd_toaddr := c.addressVar(transferfrom_cmd, "toaddr", "TO ADDRESS")
+	transferfrom_cmd_value := transferfrom_cmd.String("value", "0", "VALUE in token units")
+	transferfrom_cmd_token := transferfrom_cmd.String("token", "", "token SYMBOL or ADDRESS, default token contract if empty")
+	transferfrom_cmd_wait := transferfrom_cmd.Bool("wait", false, "wait for the transaction receipt")
+	transferfrom_cmd_confirmations := transferfrom_cmd.Uint64("confirmations", 1, "CONFIRMATIONS to wait for")
+	transferfrom_cmd_simulate := transferfrom_cmd.Bool("simulate", false, "simulate the transaction and preview balance changes without sending")
+
+	switch args[0] {
 	case "createwallet":
-		err := cw_cmd.Parse(os.Args[2:])
+		err := cw_cmd.Parse(args[1:])
 		if err != nil {
 			fmt.Println("Failed to parse command line arguments", err)
 			return
 		}
 	case "transfer":
-		err := transfer_cmd.Parse(os.Args[2:])
+		err := transfer_cmd.Parse(args[1:])
 		if err != nil {
 			fmt.Println("Failed to parse command line arguments", err)
 			return
 		}
+	case "batchtransfer":
+		err := batchtransfer_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse batchtransfer_cmd", err)
+			return
+		}
 	case "balance":
-		err := balance_cmd.Parse(os.Args[2:])
+		err := balance_cmd.Parse(args[1:])
 		if err != nil {
 			fmt.Println("Failed to parse balance_cmd", err)
 			return
 		}
 	case "sendtoken":
-		err := sendtoken_cmd.Parse(os.Args[2:])
+		err := sendtoken_cmd.Parse(args[1:])
 		if err != nil {
 			fmt.Println("Failed to parse sendtoken_cmd", err)
 			return
 		}
 	case "tokenbalance":
-		err := tokenbalance_cmd.Parse(os.Args[2:])
+		err := tokenbalance_cmd.Parse(args[1:])
 		if err != nil {
 			fmt.Println("Failed to parse tokenbalance_cmd", err)
 			return
 		}
 	case "detail":
-		err := detail_cmd.Parse(os.Args[2:])
+		err := detail_cmd.Parse(args[1:])
 		if err != nil {
 			fmt.Println("Failed to parse detail_cmd", err)
 			return
 		}
+	case "policy":
+		err := policy_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse policy_cmd", err)
+			return
+		}
+	case "auditverify":
+		err := auditverify_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse auditverify_cmd", err)
+			return
+		}
+	case "serve":
+		err := serve_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse serve_cmd", err)
+			return
+		}
+	case "txstatus":
+		err := txstatus_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse txstatus_cmd", err)
+			return
+		}
+	case "buildtx":
+		err := buildtx_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse buildtx_cmd", err)
+			return
+		}
+	case "signtx":
+		err := signtx_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse signtx_cmd", err)
+			return
+		}
+	case "broadcast":
+		err := broadcast_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse broadcast_cmd", err)
+			return
+		}
+	case "decodetx":
+		err := decodetx_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse decodetx_cmd", err)
+			return
+		}
+	case "signmessage":
+		err := signmessage_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse signmessage_cmd", err)
+			return
+		}
+	case "verifymessage":
+		err := verifymessage_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse verifymessage_cmd", err)
+			return
+		}
+	case "signtypeddata":
+		err := signtypeddata_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse signtypeddata_cmd", err)
+			return
+		}
+	case "verifytypeddata":
+		err := verifytypeddata_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse verifytypeddata_cmd", err)
+			return
+		}
+	case "addtoken":
+		err := addtoken_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse addtoken_cmd", err)
+			return
+		}
+	case "removetoken":
+		err := removetoken_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse removetoken_cmd", err)
+			return
+		}
+	case "tokens":
+		err := tokens_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse tokens_cmd", err)
+			return
+		}
+	case "history":
+		err := history_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse history_cmd", err)
+			return
+		}
+	case "export":
+		err := export_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse export_cmd", err)
+			return
+		}
+	case "call":
+		err := call_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse call_cmd", err)
+			return
+		}
+	case "send":
+		err := send_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse send_cmd", err)
+			return
+		}
+	case "abi":
+		var cmd *flag.FlagSet
+		if len(args) > 1 {
+			cmd = map[string]*flag.FlagSet{
+				"encode":    abiencode_cmd,
+				"decode":    abidecode_cmd,
+				"decodelog": abidecodelog_cmd,
+				"import":    abiimport_cmd,
+			}[args[1]]
+		}
+		if cmd == nil {
+			c.Help()
+			os.Exit(1)
+		}
+		err := cmd.Parse(args[2:])
+		if err != nil {
+			fmt.Println("Failed to parse abi command", err)
+			return
+		}
+	case "contacts":
+		var cmd *flag.FlagSet
+		if len(args) > 1 {
+			cmd = map[string]*flag.FlagSet{
+				"add":    contactsadd_cmd,
+				"list":   contactslist_cmd,
+				"remove": contactsremove_cmd,
+			}[args[1]]
+		}
+		if cmd == nil {
+			c.Help()
+			os.Exit(1)
+		}
+		err := cmd.Parse(args[2:])
+		if err != nil {
+			fmt.Println("Failed to parse contacts command", err)
+			return
+		}
+	case "balances":
+		err := balances_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse balances_cmd", err)
+			return
+		}
+	case "watch":
+		err := watch_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse watch_cmd", err)
+			return
+		}
+	case "deploytoken":
+		err := deploytoken_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse deploytoken_cmd", err)
+			return
+		}
+	case "mint":
+		err := mint_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse mint_cmd", err)
+			return
+		}
+	case "approve":
+		err := approve_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse approve_cmd", err)
+			return
+		}
+	case "allowance":
+		err := allowance_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse allowance_cmd", err)
+			return
+		}
+	case "transferfrom":
+		err := transferfrom_cmd.Parse(args[1:])
+		if err != nil {
+			fmt.Println("Failed to parse transferfrom_cmd", err)
+			return
+		}
 	}
 
 	if cw_cmd.Parsed() {
@@ -122,7 +655,44 @@ func (c Client) Run() {
 
 	if transfer_cmd.Parsed() {
 		fmt.Println("params is", *transfer_cmd_from, *transfer_cmd_toaddr, *transfer_cmd_value)
-		c.transfer(*transfer_cmd_from, *transfer_cmd_toaddr, *transfer_cmd_value)
+		amount, err := units.ParseEther(*transfer_cmd_value)
+		if err != nil {
+			fmt.Println("Invalid value", err)
+			os.Exit(1)
+		}
+		data, err := parseTxData(*transfer_cmd_data, *transfer_cmd_memo)
+		if err != nil {
+			fmt.Println("Invalid data", err)
+			os.Exit(1)
+		}
+		c.simulate = *transfer_cmd_simulate
+		hash, err := c.transfer(*transfer_cmd_from, *transfer_cmd_toaddr, amount, data)
+		if errors.Is(err, errSimulated) {
+			return
+		}
+		if err != nil {
+			fmt.Println("Failed to transfer", err)
+			os.Exit(1)
+		}
+		fmt.Println("Tx hash:", hash.Hex())
+		if *transfer_cmd_wait {
+			if err := c.waitTx(hash, *transfer_cmd_confirmations); err != nil {
+				fmt.Println("Failed to wait for transaction", err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	if batchtransfer_cmd.Parsed() {
+		c.simulate = *batchtransfer_cmd_simulate
+		err := c.batchtransfer(*batchtransfer_cmd_from, *batchtransfer_cmd_file, *batchtransfer_cmd_results, *batchtransfer_cmd_interval, *batchtransfer_cmd_wait, *batchtransfer_cmd_confirmations)
+		if errors.Is(err, errSimulated) {
+			return
+		}
+		if err != nil {
+			fmt.Println("Failed to batch transfer", err)
+			os.Exit(1)
+		}
 	}
 
 	if balance_cmd.Parsed() {
@@ -131,15 +701,354 @@ func (c Client) Run() {
 	}
 
 	if sendtoken_cmd.Parsed() {
-		c.sendtoken(*sendtoken_cmd_from, *sendtoken_cmd_toaddr, *sendtoken_cmd_value)
+		c.simulate = *sendtoken_cmd_simulate
+		hash, err := c.sendtoken(*sendtoken_cmd_from, *sendtoken_cmd_toaddr, *sendtoken_cmd_value, *sendtoken_cmd_token)
+		if errors.Is(err, errSimulated) {
+			return
+		}
+		if err != nil {
+			fmt.Println("Failed to send token", err)
+			os.Exit(1)
+		}
+		fmt.Println("Tx hash:", hash.Hex())
+		if *sendtoken_cmd_wait {
+			if err := c.waitTx(hash, *sendtoken_cmd_confirmations); err != nil {
+				fmt.Println("Failed to wait for transaction", err)
+				os.Exit(1)
+			}
+		}
 	}
 
 	if tokenbalance_cmd.Parsed() {
-		c.tokenbalance(*tokenbalance_cmd_from)
+		c.tokenbalance(*tokenbalance_cmd_from, *tokenbalance_cmd_token)
 	}
 
 	if detail_cmd.Parsed() {
-		c.tokendetail(*detail_cmd_who)
+		if err := c.tokendetail(*detail_cmd_who, *detail_cmd_token, *detail_cmd_from_block, *detail_cmd_to_block); err != nil {
+			fmt.Println("Failed to get token detail", err)
+			os.Exit(1)
+		}
+	}
+
+	if policy_cmd.Parsed() {
+		if err := c.showpolicy(*policy_cmd_account); err != nil {
+			fmt.Println("Failed to show spending policy", err)
+			os.Exit(1)
+		}
+	}
+
+	if auditverify_cmd.Parsed() {
+		if err := c.auditverify(*auditverify_cmd_expect, *auditverify_cmd_show); err != nil {
+			fmt.Println("Failed to verify audit log", err)
+			os.Exit(1)
+		}
+	}
+
+	if serve_cmd.Parsed() {
+		if err := c.serve(*serve_cmd_socket, *serve_cmd_http, *serve_cmd_rules); err != nil {
+			fmt.Println("Failed to serve signer", err)
+			os.Exit(1)
+		}
+	}
+
+	if txstatus_cmd.Parsed() {
+		if err := c.txstatus(*txstatus_cmd_tx); err != nil {
+			fmt.Println("Failed to get transaction status", err)
+			os.Exit(1)
+		}
+	}
+
+	if buildtx_cmd.Parsed() {
+		amount, err := units.ParseEther(*buildtx_cmd_value)
+		if err != nil {
+			fmt.Println("Invalid value", err)
+			os.Exit(1)
+		}
+		data, err := parseTxData(*buildtx_cmd_data, *buildtx_cmd_memo)
+		if err != nil {
+			fmt.Println("Invalid data", err)
+			os.Exit(1)
+		}
+		if err := c.buildtx(*buildtx_cmd_from, *buildtx_cmd_toaddr, amount, data, *buildtx_cmd_format, *buildtx_cmd_out); err != nil {
+			fmt.Println("Failed to build transaction", err)
+			os.Exit(1)
+		}
+	}
+
+	if signtx_cmd.Parsed() {
+		if err := c.signtx(*signtx_cmd_from, *signtx_cmd_in, *signtx_cmd_out); err != nil {
+			fmt.Println("Failed to sign transaction", err)
+			os.Exit(1)
+		}
+	}
+
+	if broadcast_cmd.Parsed() {
+		c.simulate = *broadcast_cmd_simulate
+		hash, err := c.broadcast(*broadcast_cmd_in, *broadcast_cmd_raw)
+		if errors.Is(err, errSimulated) {
+			return
+		}
+		if err != nil {
+			fmt.Println("Failed to broadcast transaction", err)
+			os.Exit(1)
+		}
+		fmt.Println("Tx hash:", hash.Hex())
+		if *broadcast_cmd_wait {
+			if err := c.waitTx(hash, *broadcast_cmd_confirmations); err != nil {
+				fmt.Println("Failed to wait for transaction", err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	if decodetx_cmd.Parsed() {
+		if err := c.decodetx(*decodetx_cmd_in, *decodetx_cmd_raw); err != nil {
+			fmt.Println("Failed to decode transaction", err)
+			os.Exit(1)
+		}
+	}
+
+	if signmessage_cmd.Parsed() {
+		sig, err := c.signmessage(*signmessage_cmd_from, *signmessage_cmd_msg, *signmessage_cmd_file)
+		if err != nil {
+			fmt.Println("Failed to sign message", err)
+			os.Exit(1)
+		}
+		fmt.Println("Signature:", sig)
+	}
+
+	if verifymessage_cmd.Parsed() {
+		if err := c.verifymessage(*verifymessage_cmd_address, *verifymessage_cmd_msg, *verifymessage_cmd_file, *verifymessage_cmd_sig); err != nil {
+			fmt.Println("Failed to verify message", err)
+			os.Exit(1)
+		}
+	}
+
+	if signtypeddata_cmd.Parsed() {
+		sig, err := c.signtypeddata(*signtypeddata_cmd_from, *signtypeddata_cmd_file)
+		if err != nil {
+			fmt.Println("Failed to sign typed data", err)
+			os.Exit(1)
+		}
+		fmt.Println("Signature:", sig)
+	}
+
+	if verifytypeddata_cmd.Parsed() {
+		if err := c.verifytypeddata(*verifytypeddata_cmd_address, *verifytypeddata_cmd_file, *verifytypeddata_cmd_sig); err != nil {
+			fmt.Println("Failed to verify typed data", err)
+			os.Exit(1)
+		}
+	}
+
+	if addtoken_cmd.Parsed() {
+		if err := c.addtoken(*addtoken_cmd_address, *addtoken_cmd_symbol); err != nil {
+			fmt.Println("Failed to add token", err)
+			os.Exit(1)
+		}
+	}
+
+	if removetoken_cmd.Parsed() {
+		if err := c.removetoken(*removetoken_cmd_token); err != nil {
+			fmt.Println("Failed to remove token", err)
+			os.Exit(1)
+		}
+	}
+
+	if tokens_cmd.Parsed() {
+		if err := c.tokens(); err != nil {
+			fmt.Println("Failed to list tokens", err)
+			os.Exit(1)
+		}
+	}
+
+	if history_cmd.Parsed() {
+		err := c.history(*history_cmd_account, *history_cmd_asset, *history_cmd_direction, *history_cmd_from, *history_cmd_to, *history_cmd_index_from, *history_cmd_offline)
+		if err != nil {
+			fmt.Println("Failed to get history", err)
+			os.Exit(1)
+		}
+	}
+
+	if export_cmd.Parsed() {
+		err := c.export(*export_cmd_account, *export_cmd_from, *export_cmd_to, *export_cmd_format, *export_cmd_asset, *export_cmd_out)
+		if err != nil {
+			fmt.Println("Failed to export", err)
+			os.Exit(1)
+		}
+	}
+
+	if call_cmd.Parsed() {
+		if err := c.call(*call_cmd_contract, *call_cmd_abi, *call_cmd_method, *call_cmd_from, *call_cmd_block, call_cmd.Args()); err != nil {
+			fmt.Println("Failed to call contract", err)
+			os.Exit(1)
+		}
+	}
+
+	if send_cmd.Parsed() {
+		value, err := units.ParseEther(*send_cmd_value)
+		if err != nil {
+			fmt.Println("Invalid value", err)
+			os.Exit(1)
+		}
+		c.simulate = *send_cmd_simulate
+		hash, err := c.send(*send_cmd_from, *send_cmd_contract, *send_cmd_abi, *send_cmd_method, value, send_cmd.Args())
+		if errors.Is(err, errSimulated) {
+			return
+		}
+		if err != nil {
+			fmt.Println("Failed to send transaction", err)
+			os.Exit(1)
+		}
+		fmt.Println("Tx hash:", hash.Hex())
+		if *send_cmd_wait {
+			if err := c.waitTx(hash, *send_cmd_confirmations); err != nil {
+				fmt.Println("Failed to wait for transaction", err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	if abiencode_cmd.Parsed() {
+		data, err := c.abiencode(*abiencode_cmd_abi, *abiencode_cmd_method, abiencode_cmd.Args())
+		if err != nil {
+			fmt.Println("Failed to encode calldata", err)
+			os.Exit(1)
+		}
+		fmt.Println(data)
+	}
+
+	if abidecode_cmd.Parsed() {
+		if err := c.abidecode(*abidecode_cmd_abi, *abidecode_cmd_method, *abidecode_cmd_data); err != nil {
+			fmt.Println("Failed to decode calldata", err)
+			os.Exit(1)
+		}
+	}
+
+	if abidecodelog_cmd.Parsed() {
+		if err := c.abidecodelog(*abidecodelog_cmd_abi, *abidecodelog_cmd_event, *abidecodelog_cmd_topics, *abidecodelog_cmd_data, *abidecodelog_cmd_tx); err != nil {
+			fmt.Println("Failed to decode logs", err)
+			os.Exit(1)
+		}
+	}
+
+	if abiimport_cmd.Parsed() {
+		if err := c.abiimport(*abiimport_cmd_abi, *abiimport_cmd_sig, *abiimport_cmd_event); err != nil {
+			fmt.Println("Failed to import signatures", err)
+			os.Exit(1)
+		}
+	}
+
+	if contactsadd_cmd.Parsed() {
+		if err := c.contactsadd(*contactsadd_cmd_label, *contactsadd_cmd_address, *contactsadd_cmd_note); err != nil {
+			fmt.Println("Failed to add contact", err)
+			os.Exit(1)
+		}
+	}
+
+	if contactslist_cmd.Parsed() {
+		if err := c.contactslist(); err != nil {
+			fmt.Println("Failed to list contacts", err)
+			os.Exit(1)
+		}
+	}
+
+	if contactsremove_cmd.Parsed() {
+		if err := c.contactsremove(*contactsremove_cmd_label); err != nil {
+			fmt.Println("Failed to remove contact", err)
+			os.Exit(1)
+		}
+	}
+
+	if balances_cmd.Parsed() {
+		err := c.balances(*balances_cmd_file, *balances_cmd_tokens, *balances_cmd_method, *balances_cmd_batch, *balances_cmd_concurrency)
+		if err != nil {
+			fmt.Println("Failed to query balances", err)
+			os.Exit(1)
+		}
+	}
+
+	if watch_cmd.Parsed() {
+		if err := c.watch(*watch_cmd_account, *watch_cmd_token, *watch_cmd_ws, *watch_cmd_from_block, *watch_cmd_interval); err != nil {
+			fmt.Println("Failed to watch events", err)
+			os.Exit(1)
+		}
+	}
+
+	if deploytoken_cmd.Parsed() {
+		c.simulate = *deploytoken_cmd_simulate
+		address, err := c.deploytoken(*deploytoken_cmd_from, *deploytoken_cmd_symbol)
+		if errors.Is(err, errSimulated) {
+			return
+		}
+		if err != nil {
+			fmt.Println("Failed to deploy token", err)
+			os.Exit(1)
+		}
+		fmt.Println("Token address:", address.Hex())
+	}
+
+	if mint_cmd.Parsed() {
+		c.simulate = *mint_cmd_simulate
+		hash, err := c.mint(*mint_cmd_from, *mint_cmd_to, *mint_cmd_value, *mint_cmd_token)
+		if errors.Is(err, errSimulated) {
+			return
+		}
+		if err != nil {
+			fmt.Println("Failed to mint", err)
+			os.Exit(1)
+		}
+		fmt.Println("Tx hash:", hash.Hex())
+		if *mint_cmd_wait {
+			if err := c.waitTx(hash, *mint_cmd_confirmations); err != nil {
+				fmt.Println("Failed to wait for transaction", err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	if approve_cmd.Parsed() {
+		c.simulate = *approve_cmd_simulate
+		hash, err := c.approve(*approve_cmd_from, *approve_cmd_spender, *approve_cmd_value, *approve_cmd_token, *approve_cmd_safe)
+		if errors.Is(err, errSimulated) {
+			return
+		}
+		if err != nil {
+			fmt.Println("Failed to approve", err)
+			os.Exit(1)
+		}
+		fmt.Println("Tx hash:", hash.Hex())
+		if *approve_cmd_wait {
+			if err := c.waitTx(hash, *approve_cmd_confirmations); err != nil {
+				fmt.Println("Failed to wait for transaction", err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	if allowance_cmd.Parsed() {
+		if _, err := c.allowance(*allowance_cmd_owner, *allowance_cmd_spender, *allowance_cmd_token); err != nil {
+			fmt.Println("Failed to get allowance", err)
+			os.Exit(1)
+		}
+	}
+
+	if transferfrom_cmd.Parsed() {
+		c.simulate = *transferfrom_cmd_simulate
+		hash, err := c.transferfrom(*transferfrom_cmd_spender, *transferfrom_cmd_from, *transferfrom_cmd_toaddr, *transferfrom_cmd_value, *transferfrom_cmd_token)
+		if errors.Is(err, errSimulated) {
+			return
+		}
+		if err != nil {
+			fmt.Println("Failed to transfer from", err)
+			os.Exit(1)
+		}
+		fmt.Println("Tx hash:", hash.Hex())
+		if *transferfrom_cmd_wait {
+			if err := c.waitTx(hash, *transferfrom_cmd_confirmations); err != nil {
+				fmt.Println("Failed to wait for transaction", err)
+				os.Exit(1)
+			}
+		}
 	}
 }
 
@@ -148,27 +1057,45 @@ func (c *Client) createWallet(pass string) error {
 	return w.StoreKey(pass)
 }
 
-func (c *Client) transfer(from, to string, value int64) error {
-	w, _ := hdwallet.LoadWallet(from, c.dataDir)
-	cli, _ := ethclient.Dial(c.network)
+func (c *Client) transfer(from, to string, amount *big.Int, data []byte) (common.Hash, error) {
+	cli, err := c.dial()
+	if err != nil {
+		return common.Hash{}, err
+	}
 	defer cli.Close()
-	nonce, _ := cli.NonceAt(context.Background(), common.HexToAddress(from), nil)
+	nonce, err := cli.PendingNonceAt(context.Background(), common.HexToAddress(from))
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get nonce: %w", err)
+	}
 
 	gaslimit := uint64(210000)
 	gasprice := big.NewInt(5000000000)
-	amount := big.NewInt(value)
-	tx := types.NewTransaction(nonce, common.HexToAddress(to), amount, gaslimit, gasprice, []byte("Salary"))
-	signedTx, err := w.HDKeyStore.SignTx(common.HexToAddress(from), tx, chainID)
+	toAddr := common.HexToAddress(to)
+	c.warnNewRecipient(common.HexToAddress(from), toAddr)
+	// 接收方是合约时转账可能被拒绝，先模拟执行，避免发送注定失败的交易。
+	msg := ethereum.CallMsg{From: common.HexToAddress(from), To: &toAddr, Gas: gaslimit, GasPrice: gasprice, Value: amount, Data: data}
+	if err := c.simulateTx(context.Background(), cli, msg); err != nil {
+		return common.Hash{}, err
+	}
+	w, err := hdwallet.LoadWallet(from, c.dataDir)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	tx := types.NewTransaction(nonce, toAddr, amount, gaslimit, gasprice, data)
+	signedTx, err := w.HDKeyStore.SignTx(common.HexToAddress(from), tx, c.chainID)
 	if err != nil {
-		fmt.Println("Failed to sign tx", err)
+		return common.Hash{}, fmt.Errorf("failed to sign tx: %w", err)
 	}
-	return cli.SendTransaction(context.Background(), signedTx)
+	if err := cli.SendTransaction(context.Background(), signedTx); err != nil {
+		return common.Hash{}, err
+	}
+	return signedTx.Hash(), nil
 }
 
-func (c *Client) balance(from string) (int64, error) {
-	cli, err := ethclient.Dial(c.network)
+func (c *Client) balance(from string) (*big.Int, error) {
+	cli, err := c.dial()
 	if err != nil {
-		log.Panic("Failed to connect to Ethereum network")
+		log.Panic("Failed to connect to Ethereum network", err)
 	}
 	defer cli.Close()
 
@@ -177,36 +1104,68 @@ func (c *Client) balance(from string) (int64, error) {
 	if err != nil {
 		log.Panic("Failed to get balance", err, from)
 	}
-	fmt.Println("Balance of", from, "is", value)
-	return value.Int64(), nil
+	fmt.Println("Balance of", c.newENSNames(context.Background(), cli).format(addr), "is", units.FormatEther(value), "ETH")
+	return value, nil
 }
 
-func (c *Client) sendtoken(from, to string, value int64) error {
-	cli, _ := ethclient.Dial(c.network)
+func (c *Client) sendtoken(from, to, value, tokenName string) (common.Hash, error) {
+	cli, err := c.dial()
+	if err != nil {
+		return common.Hash{}, err
+	}
 	defer cli.Close()
 
-	token, _ := sol.NewToken(common.HexToAddress(TokenContractAddress), cli)
+	info, err := c.resolveToken(cli, tokenName)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	token, err := sol.NewToken(info.Address, cli)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get token contract: %w", err)
+	}
+	amount, err := units.ParseUnits(value, int(info.Decimals))
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	c.warnNewRecipient(common.HexToAddress(from), common.HexToAddress(to))
+	parsed, err := sol.TokenMetaData.GetAbi()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := c.simulateContract(context.Background(), cli, common.HexToAddress(from), info.Address, parsed, nil, "transfer", common.HexToAddress(to), amount); err != nil {
+		return common.Hash{}, err
+	}
 
-	w, _ := hdwallet.LoadWallet(from, c.dataDir)
-	auth, _ := w.HDKeyStore.NewTransactOpts(chainID)
-	_, err := token.Transfer(auth, common.HexToAddress(to), big.NewInt(value))
-	return err
+	w, err := hdwallet.LoadWallet(from, c.dataDir)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	auth, err := w.HDKeyStore.NewTransactOpts(c.chainID)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	tx, err := token.Transfer(auth, common.HexToAddress(to), amount)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
 }
 
-func (c *Client) tokenbalance(from string) (int64, error) {
-	cli, err := ethclient.Dial(c.network)
+func (c *Client) tokenbalance(from, tokenName string) (*big.Int, error) {
+	cli, err := c.dial()
 	if err != nil {
 		log.Panic("Failed to connect to the Ethereum client", err)
 	}
 	defer cli.Close()
 
-	// 检查合约地址是否有代码
-	code, err := cli.CodeAt(context.Background(), common.HexToAddress(TokenContractAddress), nil)
-	if err != nil || len(code) == 0 {
-		log.Panic("No contract code at given address")
+	// 解析代币，同时检查合约地址是否有代码
+	info, err := c.resolveToken(cli, tokenName)
+	if err != nil {
+		log.Panic("Failed to resolve token ", err)
 	}
 
-	token, err := sol.NewToken(common.HexToAddress(TokenContractAddress), cli)
+	token, err := sol.NewToken(info.Address, cli)
 	if err != nil {
 		log.Panic("Failed to get token contract", err)
 	}
@@ -218,61 +1177,106 @@ func (c *Client) tokenbalance(from string) (int64, error) {
 	if err != nil {
 		log.Panic("Failed to get token balance", err)
 	}
-	fmt.Printf("%s's token balance: %d\n", from, value.Int64())
-	return value.Int64(), nil
+	who := c.newENSNames(context.Background(), cli).format(fromaddr)
+	fmt.Printf("%s's token balance: %s %s\n", who, units.FormatUnits(value, int(info.Decimals)), info.Symbol)
+	return value, nil
 }
 
 // tokendetail 函数获取指定地址的代币转账记录。
+// who 是数据目录中的账户且已建立本地索引时，增量索引后从数据库查询；
+// 否则通过 Transfer 事件的主题过滤发送方或接收方为 who 的日志，按区块范围分页查询。
 // 参数:
 //
 //	who - 要查询的以太坊地址。
+//	tokenName - 代币符号或合约地址，为空时使用配置的默认合约。
+//	fromBlock - 起始区块。
+//	toBlock - 结束区块，为 0 时查询到最新区块。
 //
 // 返回值:
 //
 //	如果查询过程中发生错误，则返回错误。
-func (c *Client) tokendetail(who string) error {
+func (c *Client) tokendetail(who, tokenName string, fromBlock, toBlock uint64) error {
+	if !common.IsHexAddress(who) {
+		return fmt.Errorf("invalid address %q", who)
+	}
 	// 连接到以太坊客户端。
-	cli, err := ethclient.Dial(c.network)
+	cli, err := c.dial()
 	if err != nil {
-		log.Panic("Failed to connect to the Ethereum client", err)
+		return err
 	}
 	defer cli.Close()
 
-	// 初始化过滤查询，以获取代币转账事件的日志。
-	query := ethereum.FilterQuery{
-		Addresses: []common.Address{},
-		Topics:    [][]common.Hash{{}},
+	// 解析代币，精度用于格式化转账金额。
+	info, err := c.resolveToken(cli, tokenName)
+	if err != nil {
+		return err
 	}
-	// 将合约地址转换为以太坊地址对象。
-	cAddress := common.HexToAddress(TokenContractAddress)
-	// 计算代币转账事件的主题哈希。
-	topicHash := crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
 
-	// 使用过滤查询获取日志。
-	logs, err := cli.FilterLogs(context.Background(), query)
+	ctx := context.Background()
+	if toBlock == 0 {
+		if toBlock, err = cli.BlockNumber(ctx); err != nil {
+			return fmt.Errorf("failed to get latest block: %w", err)
+		}
+	}
+	if fromBlock > toBlock {
+		return fmt.Errorf("from block %d is after to block %d", fromBlock, toBlock)
+	}
+
+	// 查询数据目录中的账户时优先使用本地索引，否则扫描链上日志。
+	whoAddr := common.HexToAddress(who)
+	tokens, err := c.trackedTokens(cli, info)
 	if err != nil {
-		log.Panic("Failed to filter logs", err)
-	}
-
-	// 遍历日志，查找与指定地址相关的代币转账记录。
-	for _, v := range logs {
-		if cAddress == v.Address {
-			if len(v.Topics) == 3 {
-				if v.Topics[0] == topicHash {
-					// 提取转账事件的日志数据。
-					fromF := v.Topics[1].Bytes()[len(v.Topics[1].Bytes())-20:]
-					to := v.Topics[2].Bytes()[len(v.Topics[2].Bytes())-20:]
-					val := big.NewInt(0)
-					val.SetBytes(v.Data)
-					// 检查转账事件的发送方或接收方是否为指定地址。
-					if strings.EqualFold(fmt.Sprintf("0x%x", fromF), who) {
-						fmt.Printf(" from : 0x%x\n to : 0x%x\n value : %d\n BlockNumber : %d\n", fromF, to, val.Int64(), v.BlockNumber)
-						if strings.EqualFold(fmt.Sprintf("0x%x", to), who) {
-							fmt.Printf(" from : 0x%x\n to : 0x%x\n value : %d\n BlockNumber : %d\n", fromF, to, val.Int64(), v.BlockNumber)
-						}
-					}
-				}
+		return err
+	}
+	filter := history.Filter{Token: &info.Address, FromBlock: fromBlock, ToBlock: toBlock}
+	records, indexed, err := c.queryHistory(ctx, cli, tokens, whoAddr, filter)
+	if err != nil {
+		return err
+	}
+	if !indexed {
+		records, err = tokenRecords(ctx, cli, info, []common.Address{whoAddr}, fromBlock, toBlock, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	names := c.newENSNames(ctx, cli)
+	fmt.Printf("%d %s transfer(s) of %s in blocks %d-%d\n", len(records), info.Symbol, names.format(whoAddr), fromBlock, toBlock)
+	if len(records) == 0 {
+		return nil
+	}
+	decimals := int(info.Decimals)
+	isWho := func(addr common.Address) bool { return addr == whoAddr }
+	in, out := new(big.Int), new(big.Int)
+	fmt.Printf("%-8s %-19s %-4s %-42s %-42s %20s %s\n", "BLOCK", "TIME (UTC)", "DIR", "FROM", "TO", "VALUE", "TX HASH")
+	for _, r := range records {
+		dir := recordDirection(r, isWho)
+		switch dir {
+		case "IN":
+			in.Add(in, r.Value)
+		case "OUT":
+			out.Add(out, r.Value)
+		}
+		fmt.Printf("%-8d %-19s %-4s %-42s %-42s %20s %s\n", r.BlockNumber, time.Unix(int64(r.Time), 0).UTC().Format(time.DateTime),
+			dir, r.From.Hex(), r.To.Hex(), units.FormatUnits(r.Value, decimals), r.TxHash.Hex())
+	}
+	fmt.Printf("Total in: %s %s, total out: %s %s\n", units.FormatUnits(in, decimals), info.Symbol, units.FormatUnits(out, decimals), info.Symbol)
+
+	// 列出交易对手方的主 ENS 名称。
+	var named []common.Address
+	seen := map[common.Address]bool{whoAddr: true}
+	for _, r := range records {
+		for _, addr := range []common.Address{r.From, r.To} {
+			if !seen[addr] && names.name(addr) != "" {
+				named = append(named, addr)
 			}
+			seen[addr] = true
+		}
+	}
+	if len(named) > 0 {
+		fmt.Println("ENS names:")
+		for _, addr := range named {
+			fmt.Printf("  %s %s\n", addr.Hex(), names.name(addr))
 		}
 	}
 	return nil
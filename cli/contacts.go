@@ -0,0 +1,239 @@
+package client
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go_wallet/contacts"
+	"go_wallet/hdwallet"
+	"go_wallet/history"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// loadContacts 加载数据目录下的通讯录。
+func (c *Client) loadContacts() (*contacts.Book, error) {
+	return contacts.Load(filepath.Join(c.dataDir, contacts.FileName))
+}
+
+// parseChecksumAddress 严格解析十六进制地址并校验 EIP-55 校验和。
+// 大小写混合的地址必须与校验和一致；全小写或全大写的地址不含校验和，checksummed 返回 false。
+func parseChecksumAddress(s string) (addr common.Address, checksummed bool, err error) {
+	if !common.IsHexAddress(s) {
+		return common.Address{}, false, fmt.Errorf("invalid address %q, expected 0x followed by 40 hex digits", s)
+	}
+	addr = common.HexToAddress(s)
+	digits := s[len(s)-40:]
+	if digits == strings.ToLower(digits) || digits == strings.ToUpper(digits) {
+		return addr, false, nil
+	}
+	if digits != addr.Hex()[2:] {
+		return common.Address{}, false, fmt.Errorf("address %q has an invalid EIP-55 checksum", s)
+	}
+	return addr, true, nil
+}
+
+// lookupAddress 将十六进制地址或通讯录标签解析为地址。
+// 通讯录中的地址视为已校验；十六进制地址按 parseChecksumAddress 的规则校验。
+func lookupAddress(book *contacts.Book, s string) (addr common.Address, checked bool, err error) {
+	if common.IsHexAddress(s) || strings.HasPrefix(strings.ToLower(s), "0x") {
+		return parseChecksumAddress(s)
+	}
+	if contacts.ValidLabel(s) != nil {
+		return common.Address{}, false, fmt.Errorf("invalid address %q, expected 0x followed by 40 hex digits or a contact label", s)
+	}
+	contact, ok := book.Find(s)
+	if !ok {
+		return common.Address{}, false, fmt.Errorf("unknown contact %q, add it with contacts add", s)
+	}
+	return contact.Address, true, nil
+}
+
+// resolveAddress 将十六进制地址、通讯录标签或 ENS 名称解析为地址，地址不含校验和时给出警告。
+// ENS 名称通过配置网络上的注册表和解析器合约解析，并打印解析结果以便核对。
+func (c *Client) resolveAddress(s string) (common.Address, error) {
+	if isENSName(s) {
+		addr, err := c.resolveENSName(s)
+		if err != nil {
+			return common.Address{}, err
+		}
+		fmt.Printf("Resolved ENS name %s to %s\n", s, addr.Hex())
+		return addr, nil
+	}
+	book, err := c.loadContacts()
+	if err != nil {
+		return common.Address{}, err
+	}
+	addr, checked, err := lookupAddress(book, s)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if !checked {
+		fmt.Printf("Warning: address %s has no EIP-55 checksum and could not be checked for typos\n", s)
+	}
+	return addr, nil
+}
+
+// addressFlag 是地址类型的命令行参数，接受十六进制地址、通讯录标签或 ENS 名称，
+// 在解析参数时严格校验，保存带校验和的十六进制地址。
+type addressFlag struct {
+	c     *Client
+	value string
+}
+
+func (f *addressFlag) String() string { return f.value }
+
+func (f *addressFlag) Set(s string) error {
+	addr, err := f.c.resolveAddress(strings.TrimSpace(s))
+	if err != nil {
+		return err
+	}
+	f.value = addr.Hex()
+	return nil
+}
+
+// addressListFlag 是逗号分隔的地址列表参数，每一项的规则与 addressFlag 相同。
+type addressListFlag addressFlag
+
+func (f *addressListFlag) String() string { return f.value }
+
+func (f *addressListFlag) Set(s string) error {
+	var addrs []string
+	for _, item := range strings.Split(s, ",") {
+		addr, err := f.c.resolveAddress(strings.TrimSpace(item))
+		if err != nil {
+			return err
+		}
+		addrs = append(addrs, addr.Hex())
+	}
+	f.value = strings.Join(addrs, ",")
+	return nil
+}
+
+// addressVar 在 fs 中定义一个地址参数，返回解析后的十六进制地址，未指定时为空字符串。
+func (c *Client) addressVar(fs *flag.FlagSet, name, usage string) *string {
+	f := &addressFlag{c: c}
+	fs.Var(f, name, usage+", a contact LABEL or an ENS NAME")
+	return &f.value
+}
+
+// addressListVar 在 fs 中定义一个逗号分隔的地址列表参数。
+func (c *Client) addressListVar(fs *flag.FlagSet, name, usage string) *string {
+	f := &addressListFlag{c: c}
+	fs.Var(f, name, usage+", contact LABELS or ENS NAMES")
+	return &f.value
+}
+
+// knownRecipients 返回判断地址是否为已知收款方的函数：通讯录中的地址、数据目录中的账户，
+// 以及本地历史记录中 from 曾经转账过的地址。历史记录数据库不存在或被占用时只使用前两者。
+func (c *Client) knownRecipients(from common.Address) (func(common.Address) bool, error) {
+	known := make(map[common.Address]bool)
+	book, err := c.loadContacts()
+	if err != nil {
+		return nil, err
+	}
+	for _, contact := range book.Contacts {
+		known[contact.Address] = true
+	}
+	accounts, err := hdwallet.ListAccounts(c.dataDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range accounts {
+		known[a] = true
+	}
+	if _, err := os.Stat(filepath.Join(c.dataDir, history.FileName)); err == nil && c.chainID != nil {
+		if store, err := c.openHistory(); err == nil {
+			defer store.Close()
+			records, err := store.Query(c.chainID, history.Filter{Account: &from, Direction: "out"})
+			if err == nil {
+				for _, r := range records {
+					known[r.To] = true
+				}
+			}
+		}
+	}
+	return func(addr common.Address) bool { return known[addr] }, nil
+}
+
+// warnNewRecipient 在收款地址不在通讯录、数据目录和转账历史中时给出警告。
+func (c *Client) warnNewRecipient(from, to common.Address) {
+	known, err := c.knownRecipients(from)
+	if err != nil || known(to) {
+		return
+	}
+	fmt.Printf("Warning: %s is a first-time recipient, not found in contacts or history; double check the address\n", to.Hex())
+}
+
+// contactsadd 将地址加入通讯录。
+// 参数:
+//
+//	label - 标签，1 到 64 个字母、数字、下划线或连字符。
+//	address - 十六进制地址，大小写混合时必须符合 EIP-55 校验和。
+//	note - 备注，可以为空。
+//
+// 返回值:
+//
+//	如果地址或标签无效、标签已被使用或保存失败，则返回错误。
+func (c *Client) contactsadd(label, address, note string) error {
+	addr, checked, err := parseChecksumAddress(address)
+	if err != nil {
+		return err
+	}
+	if addr == (common.Address{}) {
+		return errors.New("refusing to add the zero address")
+	}
+	book, err := c.loadContacts()
+	if err != nil {
+		return err
+	}
+	if err := book.Add(contacts.Contact{Label: label, Address: addr, Note: note}); err != nil {
+		return err
+	}
+	if err := book.Save(); err != nil {
+		return err
+	}
+	if !checked {
+		fmt.Println("Warning: the address has no EIP-55 checksum, make sure it is correct:", addr.Hex())
+	}
+	fmt.Printf("Added contact %s: %s\n", label, addr.Hex())
+	return nil
+}
+
+// contactsremove 按标签从通讯录中删除联系人。
+func (c *Client) contactsremove(label string) error {
+	book, err := c.loadContacts()
+	if err != nil {
+		return err
+	}
+	contact, err := book.Remove(label)
+	if err != nil {
+		return err
+	}
+	if err := book.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("Removed contact %s: %s\n", contact.Label, contact.Address.Hex())
+	return nil
+}
+
+// contactslist 列出通讯录中的所有联系人。
+func (c *Client) contactslist() error {
+	book, err := c.loadContacts()
+	if err != nil {
+		return err
+	}
+	list := book.List()
+	if len(list) == 0 {
+		fmt.Println("No contacts, add one with contacts add")
+		return nil
+	}
+	fmt.Printf("%-20s %-42s %s\n", "LABEL", "ADDRESS", "NOTE")
+	for _, contact := range list {
+		fmt.Printf("%-20s %-42s %s\n", contact.Label, contact.Address.Hex(), contact.Note)
+	}
+	return nil
+}
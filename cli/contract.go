@@ -0,0 +1,367 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_wallet/hdwallet"
+	"go_wallet/units"
+	"math/big"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// parseTxData 解析交易附带的数据：data 为十六进制，memo 为 UTF-8 文本，两者只能指定一个，都为空时不附带数据。
+func parseTxData(data, memo string) ([]byte, error) {
+	switch {
+	case data != "" && memo != "":
+		return nil, errors.New("specify either -data or -memo, not both")
+	case data != "":
+		b, err := hexutil.Decode(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex data %q: %w", data, err)
+		}
+		return b, nil
+	case memo != "":
+		return []byte(memo), nil
+	}
+	return nil, nil
+}
+
+// loadABI 读取合约 ABI 文件，可以是 ABI 数组，也可以是带 abi 字段的编译产物（例如 Hardhat、Foundry 的输出）。
+func loadABI(file string) (*abi.ABI, error) {
+	if file == "" {
+		return nil, errors.New("no ABI file given, please specify -abi")
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var artifact struct {
+		ABI json.RawMessage `json:"abi"`
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("{")) && json.Unmarshal(content, &artifact) == nil && len(artifact.ABI) > 0 {
+		content = artifact.ABI
+	}
+	parsed, err := abi.JSON(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ABI file %s: %w", file, err)
+	}
+	return &parsed, nil
+}
+
+// findMethod 按方法名或完整签名（例如 transfer(address,uint256)）查找方法，
+// 方法名有重载时必须使用完整签名。
+func findMethod(parsed *abi.ABI, name string) (abi.Method, error) {
+	name = strings.ReplaceAll(name, " ", "")
+	var matches []abi.Method
+	for _, m := range parsed.Methods {
+		if m.Sig == name || m.RawName == name {
+			matches = append(matches, m)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Sig < matches[j].Sig })
+	switch len(matches) {
+	case 0:
+		return abi.Method{}, fmt.Errorf("method %q not found in ABI", name)
+	case 1:
+		return matches[0], nil
+	}
+	sigs := make([]string, len(matches))
+	for i, m := range matches {
+		sigs[i] = m.Sig
+	}
+	return abi.Method{}, fmt.Errorf("method %q is overloaded, use one of %s", name, strings.Join(sigs, ", "))
+}
+
+// parseABIArgs 按方法的参数类型解析命令行参数。
+func parseABIArgs(method abi.Method, args []string) ([]interface{}, error) {
+	if len(args) != len(method.Inputs) {
+		return nil, fmt.Errorf("%s expects %d argument(s), got %d", method.Sig, len(method.Inputs), len(args))
+	}
+	values := make([]interface{}, len(args))
+	for i, input := range method.Inputs {
+		v, err := parseABIArg(input.Type, args[i])
+		if err != nil {
+			name := input.Name
+			if name == "" {
+				name = strconv.Itoa(i)
+			}
+			return nil, fmt.Errorf("argument %s (%s): %w", name, input.Type, err)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// parseABIArg 将字符串解析为 ABI 类型对应的 Go 值。
+// 整数可以是十进制或 0x 开头的十六进制，bytes 为 0x 开头的十六进制，
+// 数组和 tuple 使用 JSON 数组，例如 [1,2,3] 或 ["0x...",100]。
+func parseABIArg(t abi.Type, s string) (interface{}, error) {
+	switch t.T {
+	case abi.AddressTy:
+		if !common.IsHexAddress(s) {
+			return nil, fmt.Errorf("invalid address %q", s)
+		}
+		return common.HexToAddress(s), nil
+	case abi.IntTy, abi.UintTy:
+		n, ok := new(big.Int).SetString(s, 0)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer %q", s)
+		}
+		// 有符号整数的负数按 -n-1 判断位数，使 int8 的范围为 -128 到 127。
+		bits := n
+		if n.Sign() < 0 {
+			bits = new(big.Int).Not(n)
+		}
+		if (t.T == abi.UintTy && (n.Sign() < 0 || n.BitLen() > t.Size)) || (t.T == abi.IntTy && bits.BitLen() >= t.Size) {
+			return nil, fmt.Errorf("%s out of range", s)
+		}
+		typ := t.GetType()
+		if typ == reflect.TypeOf(n) {
+			return n, nil
+		}
+		v := reflect.New(typ).Elem()
+		if t.T == abi.UintTy {
+			v.SetUint(n.Uint64())
+		} else {
+			v.SetInt(n.Int64())
+		}
+		return v.Interface(), nil
+	case abi.BoolTy:
+		return strconv.ParseBool(s)
+	case abi.StringTy:
+		return s, nil
+	case abi.BytesTy:
+		return hexutil.Decode(s)
+	case abi.FixedBytesTy:
+		b, err := hexutil.Decode(s)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != t.Size {
+			return nil, fmt.Errorf("expected %d bytes, got %d", t.Size, len(b))
+		}
+		v := reflect.New(t.GetType()).Elem()
+		reflect.Copy(v, reflect.ValueOf(b))
+		return v.Interface(), nil
+	case abi.SliceTy, abi.ArrayTy:
+		elems, err := splitJSONArray(s)
+		if err != nil {
+			return nil, err
+		}
+		var v reflect.Value
+		if t.T == abi.SliceTy {
+			v = reflect.MakeSlice(t.GetType(), len(elems), len(elems))
+		} else {
+			if len(elems) != t.Size {
+				return nil, fmt.Errorf("expected %d elements, got %d", t.Size, len(elems))
+			}
+			v = reflect.New(t.GetType()).Elem()
+		}
+		for i, e := range elems {
+			x, err := parseABIArg(*t.Elem, e)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			v.Index(i).Set(reflect.ValueOf(x))
+		}
+		return v.Interface(), nil
+	case abi.TupleTy:
+		elems, err := splitJSONArray(s)
+		if err != nil {
+			return nil, err
+		}
+		if len(elems) != len(t.TupleElems) {
+			return nil, fmt.Errorf("expected %d tuple components, got %d", len(t.TupleElems), len(elems))
+		}
+		v := reflect.New(t.GetType()).Elem()
+		for i, e := range elems {
+			x, err := parseABIArg(*t.TupleElems[i], e)
+			if err != nil {
+				return nil, fmt.Errorf("component %s: %w", t.TupleRawNames[i], err)
+			}
+			v.Field(i).Set(reflect.ValueOf(x))
+		}
+		return v.Interface(), nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// splitJSONArray 将 JSON 数组拆分为元素文本：字符串元素去掉引号，其他元素（数字、嵌套数组）保留原文。
+func splitJSONArray(s string) ([]string, error) {
+	var raws []json.RawMessage
+	if err := json.Unmarshal([]byte(s), &raws); err != nil {
+		return nil, fmt.Errorf("expected a JSON array, got %q", s)
+	}
+	elems := make([]string, len(raws))
+	for i, raw := range raws {
+		var str string
+		if json.Unmarshal(raw, &str) == nil {
+			elems[i] = str
+		} else {
+			elems[i] = string(raw)
+		}
+	}
+	return elems, nil
+}
+
+// formatABIValue 按 ABI 类型格式化解码后的值：地址使用校验和格式，bytes 使用十六进制，数组和 tuple 递归格式化。
+func formatABIValue(t abi.Type, v reflect.Value) string {
+	switch t.T {
+	case abi.AddressTy:
+		return v.Interface().(common.Address).Hex()
+	case abi.BytesTy, abi.FixedBytesTy, abi.HashTy:
+		b := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(b), v)
+		return hexutil.Encode(b)
+	case abi.SliceTy, abi.ArrayTy:
+		elems := make([]string, v.Len())
+		for i := range elems {
+			elems[i] = formatABIValue(*t.Elem, v.Index(i))
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	case abi.TupleTy:
+		fields := make([]string, len(t.TupleElems))
+		for i, elem := range t.TupleElems {
+			fields[i] = t.TupleRawNames[i] + ": " + formatABIValue(*elem, v.Field(i))
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// call 以 eth_call 只读方式调用任意合约方法，并按 ABI 解码返回值。
+// 参数:
+//
+//	contract - 合约地址。
+//	abiFile - 合约 ABI 文件。
+//	methodName - 方法名或完整签名。
+//	from - 调用方地址，可以为空。
+//	block - 在指定区块的状态上调用，为 0 时使用最新区块。
+//	args - 方法参数。
+//
+// 返回值:
+//
+//	如果解析参数或调用过程中发生错误，则返回错误。
+func (c *Client) call(contract, abiFile, methodName, from string, block uint64, args []string) error {
+	if !common.IsHexAddress(contract) {
+		return fmt.Errorf("invalid contract address %q", contract)
+	}
+	parsed, err := loadABI(abiFile)
+	if err != nil {
+		return err
+	}
+	method, err := findMethod(parsed, methodName)
+	if err != nil {
+		return err
+	}
+	values, err := parseABIArgs(method, args)
+	if err != nil {
+		return err
+	}
+
+	cli, err := c.dial()
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	opts := &bind.CallOpts{Context: context.Background()}
+	if from != "" {
+		opts.From = common.HexToAddress(from)
+	}
+	if block != 0 {
+		opts.BlockNumber = new(big.Int).SetUint64(block)
+	}
+	bound := bind.NewBoundContract(common.HexToAddress(contract), *parsed, cli, cli, cli)
+	var results []interface{}
+	if err := bound.Call(opts, &results, method.Name, values...); err != nil {
+		return c.explainRevert(err, parsed)
+	}
+	if len(method.Outputs) == 0 {
+		fmt.Println(method.Sig, "returned no values")
+		return nil
+	}
+	printArgs("", method.Outputs, results)
+	return nil
+}
+
+// send 签名并发送调用任意合约方法的交易。
+// 参数:
+//
+//	from - 签名账户。
+//	contract - 合约地址。
+//	abiFile - 合约 ABI 文件。
+//	methodName - 方法名或完整签名。
+//	value - 随交易发送的 ETH（wei），方法必须是 payable。
+//	args - 方法参数。
+//
+// 返回值:
+//
+//	common.Hash - 交易哈希。
+//	error - 如果解析参数或发送过程中发生错误，则返回错误。
+func (c *Client) send(from, contract, abiFile, methodName string, value *big.Int, args []string) (common.Hash, error) {
+	if !common.IsHexAddress(contract) {
+		return common.Hash{}, fmt.Errorf("invalid contract address %q", contract)
+	}
+	parsed, err := loadABI(abiFile)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	method, err := findMethod(parsed, methodName)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if value.Sign() > 0 && !method.IsPayable() {
+		return common.Hash{}, fmt.Errorf("%s is not payable and cannot receive ETH", method.Sig)
+	}
+	if method.IsConstant() {
+		fmt.Println("Warning:", method.Sig, "is a", method.StateMutability, "method, use call to read it without a transaction")
+	}
+	values, err := parseABIArgs(method, args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	cli, err := c.dial()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer cli.Close()
+
+	if err := c.simulateContract(context.Background(), cli, common.HexToAddress(from), common.HexToAddress(contract), parsed, value, method.Name, values...); err != nil {
+		return common.Hash{}, err
+	}
+
+	w, err := hdwallet.LoadWallet(from, c.dataDir)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	auth, err := w.HDKeyStore.NewTransactOpts(c.chainID)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	auth.Value = value
+	bound := bind.NewBoundContract(common.HexToAddress(contract), *parsed, cli, cli, cli)
+	tx, err := bound.Transact(auth, method.Name, values...)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	fmt.Printf("Calling %s on %s", method.Sig, common.HexToAddress(contract).Hex())
+	if value.Sign() > 0 {
+		fmt.Printf(" with %s ETH", units.FormatEther(value))
+	}
+	fmt.Println()
+	return tx.Hash(), nil
+}
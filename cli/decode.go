@@ -0,0 +1,195 @@
+package client
+
+import (
+	"fmt"
+	"go_wallet/sol"
+	"go_wallet/units"
+	"math/big"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// txTypeNames 是交易类型到可读名称的映射。
+var txTypeNames = map[uint8]string{
+	types.LegacyTxType:     "legacy",
+	types.AccessListTxType: "access list (EIP-2930)",
+	types.DynamicFeeTxType: "dynamic fee (EIP-1559)",
+	types.BlobTxType:       "blob (EIP-4844)",
+}
+
+// readHexInput 从文件或命令行参数中读取十六进制数据，文件优先。
+func readHexInput(in, raw string) (string, error) {
+	if in != "" {
+		content, err := os.ReadFile(in)
+		if err != nil {
+			return "", err
+		}
+		raw = string(content)
+	}
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("no transaction given, please specify -in or -raw")
+	}
+	return raw, nil
+}
+
+// parseAnyTx 解析已签名或未签名的交易。
+// 支持的输入包括 buildtx 生成的 JSON、EIP-155 签名负载 RLP，以及标准的交易二进制编码。
+// 对于未签名交易，返回的 chainID 来自输入本身。
+func parseAnyTx(text string) (*types.Transaction, *big.Int, error) {
+	b, err := hexutil.Decode(text)
+	if !strings.HasPrefix(text, "{") && err == nil {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(b); err == nil {
+			// 未签名的 legacy 交易无法按签名规则推导链 ID；
+			// 如果是 EIP-155 签名负载，v 字段就是链 ID。
+			if isUnsigned(tx) && tx.Type() == types.LegacyTxType {
+				if v, _, _ := tx.RawSignatureValues(); v != nil && v.Sign() > 0 {
+					return tx, v, nil
+				}
+				return tx, nil, nil
+			}
+			return tx, tx.ChainId(), nil
+		}
+	}
+	u, err := parseUnsignedTx(text)
+	if err != nil {
+		return nil, nil, err
+	}
+	return u.transaction(), u.ChainID.ToInt(), nil
+}
+
+// isUnsigned 判断交易是否未签名（签名值 r 和 s 均为 0）。
+func isUnsigned(tx *types.Transaction) bool {
+	_, r, s := tx.RawSignatureValues()
+	return (r == nil || r.Sign() == 0) && (s == nil || s.Sign() == 0)
+}
+
+// printCalldata 使用 ABI 解析 ERC20 合约调用数据并打印方法名和参数。
+func printCalldata(data []byte) {
+	parsed, err := sol.TokenMetaData.GetAbi()
+	if err != nil {
+		fmt.Println("Calldata:    failed to load token abi,", err)
+		return
+	}
+	method, err := parsed.MethodById(data)
+	if err != nil {
+		fmt.Println("Calldata:    unknown method,", err)
+		return
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		fmt.Println("Calldata:    failed to decode arguments of", method.Sig, err)
+		return
+	}
+	fmt.Println("Method:     ", method.Sig)
+	for i, input := range method.Inputs {
+		fmt.Printf("  %-10s %v\n", input.Name+":", args[i])
+	}
+}
+
+// isKnownToken 判断地址是否为配置的 ERC20 合约或代币注册表中的合约。
+func (c *Client) isKnownToken(address common.Address, txChainID *big.Int) bool {
+	if address == c.tokenAddress {
+		return true
+	}
+	if txChainID == nil {
+		txChainID = c.chainID
+	}
+	if txChainID == nil {
+		return false
+	}
+	reg, err := c.loadRegistry()
+	if err != nil {
+		return false
+	}
+	_, ok := reg.Find(txChainID, address.Hex())
+	return ok
+}
+
+// decodetx 解析原始交易并打印交易内容、发送方和签名有效性。
+// 如果交易的目标地址是配置的 ERC20 合约或注册表中的代币，则同时解析调用数据。
+// 参数:
+//
+//	in - 交易文件，与 raw 二选一。
+//	raw - 交易的十六进制编码。
+//
+// 返回值:
+//
+//	如果解析过程中发生错误，则返回错误。
+func (c *Client) decodetx(in, raw string) error {
+	text, err := readHexInput(in, raw)
+	if err != nil {
+		return err
+	}
+	tx, txChainID, err := parseAnyTx(text)
+	if err != nil {
+		return err
+	}
+
+	typeName, ok := txTypeNames[tx.Type()]
+	if !ok {
+		typeName = fmt.Sprintf("unknown (%d)", tx.Type())
+	}
+	fmt.Println("Type:       ", typeName)
+	if txChainID != nil {
+		fmt.Println("Chain ID:   ", txChainID)
+	} else {
+		fmt.Println("Chain ID:    unknown (unsigned legacy transaction)")
+	}
+	fmt.Println("Nonce:      ", tx.Nonce())
+	fmt.Println("Gas limit:  ", tx.Gas())
+	if tx.Type() == types.LegacyTxType || tx.Type() == types.AccessListTxType {
+		fmt.Println("Gas price:  ", units.FormatUnits(tx.GasPrice(), 9), "gwei")
+	} else {
+		fmt.Println("Max fee:    ", units.FormatUnits(tx.GasFeeCap(), 9), "gwei")
+		fmt.Println("Priority:   ", units.FormatUnits(tx.GasTipCap(), 9), "gwei")
+	}
+	if tx.To() != nil {
+		fmt.Println("To:         ", tx.To().Hex())
+	} else {
+		fmt.Println("To:          (contract creation)")
+	}
+	fmt.Println("Value:      ", units.FormatEther(tx.Value()), "ETH")
+
+	if isUnsigned(tx) {
+		fmt.Println("Signature:   unsigned")
+	} else {
+		v, r, s := tx.RawSignatureValues()
+		var signer types.Signer
+		if tx.Protected() {
+			signer = types.LatestSignerForChainID(tx.ChainId())
+		} else {
+			signer = types.HomesteadSigner{}
+		}
+		sender, err := types.Sender(signer, tx)
+		if err != nil {
+			fmt.Println("Signature:   invalid,", err)
+		} else {
+			fmt.Println("From:       ", sender.Hex())
+			fmt.Println("Signature:   valid")
+		}
+		fmt.Printf("  v: %d\n  r: %#x\n  s: %#x\n", v, r, s)
+		if tx.Protected() && c.chainID != nil && tx.ChainId().Cmp(c.chainID) != 0 {
+			fmt.Println("Warning: chain ID", tx.ChainId(), "differs from configured chain ID", c.chainID)
+		}
+		fmt.Println("Tx hash:    ", tx.Hash().Hex())
+	}
+
+	data := tx.Data()
+	if len(data) == 0 {
+		return nil
+	}
+	fmt.Println("Data:       ", hexutil.Encode(data))
+	if tx.To() != nil && c.isKnownToken(*tx.To(), txChainID) {
+		printCalldata(data)
+	} else if utf8.Valid(data) {
+		fmt.Printf("Data text:   %q\n", string(data))
+	}
+	return nil
+}
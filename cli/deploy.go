@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_wallet/hdwallet"
+	"go_wallet/sol"
+	"go_wallet/units"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// deploytoken 部署 sol/token.sol 中的 ERC20 合约，部署者即为合约管理员。
+// 部署成功后，合约地址会写入配置文件，作为当前网络的默认代币合约。
+// 参数:
+//
+//	from - 部署合约的账户。
+//	symbol - 代币符号。
+//
+// 返回值:
+//
+//	common.Address - 合约地址。
+//	error - 如果部署过程中发生错误，则返回错误。
+func (c *Client) deploytoken(from, symbol string) (common.Address, error) {
+	if symbol == "" {
+		return common.Address{}, errors.New("symbol must not be empty")
+	}
+	cli, err := c.dial()
+	if err != nil {
+		return common.Address{}, err
+	}
+	defer cli.Close()
+
+	parsed, err := sol.TokenMetaData.GetAbi()
+	if err != nil {
+		return common.Address{}, err
+	}
+	input, err := parsed.Pack("", symbol)
+	if err != nil {
+		return common.Address{}, err
+	}
+	msg := ethereum.CallMsg{From: common.HexToAddress(from), Data: append(common.FromHex(sol.TokenMetaData.Bin), input...)}
+	if err := c.simulateTx(context.Background(), cli, msg, parsed); err != nil {
+		return common.Address{}, err
+	}
+
+	w, err := hdwallet.LoadWallet(from, c.dataDir)
+	if err != nil {
+		return common.Address{}, err
+	}
+	auth, err := w.HDKeyStore.NewTransactOpts(c.chainID)
+	if err != nil {
+		return common.Address{}, err
+	}
+	address, tx, _, err := sol.DeployToken(auth, cli, symbol)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to deploy token: %w", err)
+	}
+	fmt.Println("Tx hash:", tx.Hash().Hex())
+
+	// 只有部署成功的合约才写入配置，因此这里总是等待交易回执。
+	ctx := context.Background()
+	fmt.Printf("Waiting for deployment of %s ...\n", address.Hex())
+	receipt, err := waitForReceipt(ctx, cli, tx.Hash(), 1)
+	if err != nil {
+		return common.Address{}, err
+	}
+	c.printReceipt(ctx, cli, tx, receipt)
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return common.Address{}, fmt.Errorf("deployment transaction %s failed", tx.Hash().Hex())
+	}
+
+	c.tokenAddress = address
+	if err := c.cfg.SaveTokenContract(c.networkName, address.Hex()); err != nil {
+		return address, fmt.Errorf("token deployed at %s but failed to update config: %w", address.Hex(), err)
+	}
+	fmt.Printf("Token contract of network %q saved to %s\n", c.networkName, c.cfg.File())
+	return address, nil
+}
+
+// mint 铸造代币，只有部署合约的账户可以调用。
+// 参数:
+//
+//	from - 合约管理员账户。
+//	to - 接收代币的地址。
+//	value - 铸造数量，按代币单位。
+//	tokenName - 代币符号或合约地址。
+//
+// 返回值:
+//
+//	common.Hash - 交易哈希。
+//	error - 如果铸造过程中发生错误，则返回错误。
+func (c *Client) mint(from, to, value, tokenName string) (common.Hash, error) {
+	if !common.IsHexAddress(to) {
+		return common.Hash{}, fmt.Errorf("invalid address %q", to)
+	}
+	cli, err := c.dial()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer cli.Close()
+
+	info, err := c.resolveToken(cli, tokenName)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	token, err := sol.NewToken(info.Address, cli)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get token contract: %w", err)
+	}
+	amount, err := units.ParseUnits(value, int(info.Decimals))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if amount.Sign() == 0 {
+		return common.Hash{}, errors.New("mint amount must be greater than 0")
+	}
+
+	parsed, err := sol.TokenMetaData.GetAbi()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := c.simulateContract(context.Background(), cli, common.HexToAddress(from), info.Address, parsed, nil, "mint", common.HexToAddress(to), amount); err != nil {
+		return common.Hash{}, fmt.Errorf("%w (only the account that deployed %s can mint)", err, info.Address.Hex())
+	}
+
+	w, err := hdwallet.LoadWallet(from, c.dataDir)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	auth, err := w.HDKeyStore.NewTransactOpts(c.chainID)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	tx, err := token.Mint(auth, common.HexToAddress(to), amount)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("%w (only the account that deployed %s can mint)", err, info.Address.Hex())
+	}
+	fmt.Printf("Minting %s %s to %s\n", units.FormatUnits(amount, int(info.Decimals)), info.Symbol, to)
+	return tx.Hash(), nil
+}
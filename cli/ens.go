@@ -0,0 +1,193 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"go_wallet/sol"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// defaultENSRegistry 是 ENS 注册表在主网和 Sepolia、Holesky 等测试网上的统一部署地址。
+var defaultENSRegistry = common.HexToAddress("0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e")
+
+// isENSName 判断字符串是否应按 ENS 名称解析：包含点号且不是十六进制地址。
+// 通讯录标签不能包含点号，因此不会与 ENS 名称混淆。
+func isENSName(s string) bool {
+	return strings.Contains(s, ".") && !strings.HasPrefix(strings.ToLower(s), "0x")
+}
+
+// normalizeENSName 对 ENS 名称做简化的规范化：转为小写，并要求每一级标签非空，只包含 ASCII 字母、数字、连字符和下划线。
+// 这里没有实现完整的 ENSIP-15 Unicode 规范化，包含非 ASCII 字符的名称会被拒绝，同时也避免了同形字符的欺骗。
+func normalizeENSName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, label := range strings.Split(name, ".") {
+		if label == "" {
+			return "", fmt.Errorf("invalid ENS name %q, empty label", name)
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+				return "", fmt.Errorf("invalid ENS name %q, only ASCII letters, digits, - and _ are supported", name)
+			}
+		}
+	}
+	return name, nil
+}
+
+// namehash 按 EIP-137 计算名称的节点哈希，名称应已规范化。
+func namehash(name string) common.Hash {
+	var node common.Hash
+	if name == "" {
+		return node
+	}
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		node = crypto.Keccak256Hash(node[:], crypto.Keccak256([]byte(labels[i])))
+	}
+	return node
+}
+
+// reverseNode 返回地址的反向解析节点，即 <小写十六进制地址>.addr.reverse 的节点哈希。
+func reverseNode(addr common.Address) common.Hash {
+	return namehash(strings.ToLower(addr.Hex()[2:]) + ".addr.reverse")
+}
+
+// ensRegistryContract 返回配置的 ENS 注册表合约，注册表地址上没有合约代码时返回错误。
+func (c *Client) ensRegistryContract(ctx context.Context, cli *ethclient.Client) (*sol.ENSRegistryCaller, error) {
+	code, err := cli.CodeAt(ctx, c.ensRegistry, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(code) == 0 {
+		return nil, fmt.Errorf("no ENS registry at %s on chain %s, set ens_registry in the config or use -ensregistry", c.ensRegistry.Hex(), c.chainID)
+	}
+	return sol.NewENSRegistryCaller(c.ensRegistry, cli)
+}
+
+// ensResolver 返回节点在注册表中设置的解析器合约，没有设置解析器时返回 nil。
+func ensResolver(ctx context.Context, cli *ethclient.Client, registry *sol.ENSRegistryCaller, node common.Hash) (*sol.ENSResolverCaller, error) {
+	opts := &bind.CallOpts{Context: ctx}
+	addr, err := registry.Resolver(opts, node)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ENS resolver: %w", err)
+	}
+	if addr == (common.Address{}) {
+		return nil, nil
+	}
+	return sol.NewENSResolverCaller(addr, cli)
+}
+
+// resolveENS 通过注册表和解析器合约将 ENS 名称正向解析为地址。
+// 参数:
+//
+//	cli - 以太坊客户端。
+//	registry - ENS 注册表合约。
+//	name - ENS 名称，例如 vitalik.eth。
+//
+// 返回值:
+//
+//	common.Address - 名称的 addr 记录。
+//	error - 如果名称无效、未注册、没有解析器或没有设置地址，则返回错误信息。
+func resolveENS(ctx context.Context, cli *ethclient.Client, registry *sol.ENSRegistryCaller, name string) (common.Address, error) {
+	name, err := normalizeENSName(name)
+	if err != nil {
+		return common.Address{}, err
+	}
+	node := namehash(name)
+	resolver, err := ensResolver(ctx, cli, registry, node)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if resolver == nil {
+		return common.Address{}, fmt.Errorf("ENS name %s is not registered or has no resolver", name)
+	}
+	addr, err := resolver.Addr(&bind.CallOpts{Context: ctx}, node)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to resolve ENS name %s: %w", name, err)
+	}
+	if addr == (common.Address{}) {
+		return common.Address{}, fmt.Errorf("ENS name %s has no address record", name)
+	}
+	return addr, nil
+}
+
+// reverseENS 反向解析地址的主 ENS 名称，并正向解析该名称确认指向同一地址，
+// 防止任何人为自己的地址声明别人的名称。没有主名称或正向解析不一致时返回空字符串。
+func reverseENS(ctx context.Context, cli *ethclient.Client, registry *sol.ENSRegistryCaller, addr common.Address) (string, error) {
+	node := reverseNode(addr)
+	resolver, err := ensResolver(ctx, cli, registry, node)
+	if err != nil || resolver == nil {
+		return "", err
+	}
+	name, err := resolver.Name(&bind.CallOpts{Context: ctx}, node)
+	if err != nil {
+		return "", fmt.Errorf("failed to get ENS name of %s: %w", addr.Hex(), err)
+	}
+	if name == "" {
+		return "", nil
+	}
+	forward, err := resolveENS(ctx, cli, registry, name)
+	if err != nil || forward != addr {
+		return "", nil
+	}
+	return strings.ToLower(name), nil
+}
+
+// resolveENSName 连接到节点，将 ENS 名称解析为地址。
+func (c *Client) resolveENSName(name string) (common.Address, error) {
+	cli, err := c.dial()
+	if err != nil {
+		return common.Address{}, err
+	}
+	defer cli.Close()
+
+	ctx := context.Background()
+	registry, err := c.ensRegistryContract(ctx, cli)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return resolveENS(ctx, cli, registry, name)
+}
+
+// ensNames 在输出中为地址附加反向解析得到的主 ENS 名称，并缓存解析结果。
+// 链上没有 ENS 注册表或解析失败时只显示地址，不影响命令本身。
+type ensNames struct {
+	ctx      context.Context
+	cli      *ethclient.Client
+	registry *sol.ENSRegistryCaller
+	names    map[common.Address]string
+}
+
+// newENSNames 创建用于显示 ENS 名称的反向解析器。
+func (c *Client) newENSNames(ctx context.Context, cli *ethclient.Client) *ensNames {
+	n := &ensNames{ctx: ctx, cli: cli, names: make(map[common.Address]string)}
+	if registry, err := c.ensRegistryContract(ctx, cli); err == nil {
+		n.registry = registry
+	}
+	return n
+}
+
+// name 返回地址的主 ENS 名称，没有时返回空字符串。
+func (n *ensNames) name(addr common.Address) string {
+	if n.registry == nil {
+		return ""
+	}
+	name, ok := n.names[addr]
+	if !ok {
+		name, _ = reverseENS(n.ctx, n.cli, n.registry, addr)
+		n.names[addr] = name
+	}
+	return name
+}
+
+// format 返回带 ENS 名称的地址，例如 0x... (vitalik.eth)。
+func (n *ensNames) format(addr common.Address) string {
+	if name := n.name(addr); name != "" {
+		return addr.Hex() + " (" + name + ")"
+	}
+	return addr.Hex()
+}
@@ -0,0 +1,209 @@
+package client
+
+import (
+	"context"
+	"go_wallet/sol"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+)
+
+func TestNamehash(t *testing.T) {
+	// EIP-137 中的测试向量。
+	tests := map[string]string{
+		"":        "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"eth":     "0x93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae",
+		"foo.eth": "0xde9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f",
+	}
+	for name, want := range tests {
+		if got := namehash(name); got != common.HexToHash(want) {
+			t.Errorf("namehash(%q) = %s, want %s", name, got.Hex(), want)
+		}
+	}
+
+	addr := common.HexToAddress("0x2c7536E3605D9C16a7a3D7b1898e529396a65c23")
+	if got, want := reverseNode(addr), namehash("2c7536e3605d9c16a7a3d7b1898e529396a65c23.addr.reverse"); got != want {
+		t.Errorf("reverseNode = %s, want %s", got.Hex(), want.Hex())
+	}
+}
+
+func TestNormalizeENSName(t *testing.T) {
+	if got, err := normalizeENSName(" Foo.ETH "); err != nil || got != "foo.eth" {
+		t.Errorf("normalizeENSName = %q, %v, want foo.eth", got, err)
+	}
+	for _, name := range []string{"foo..eth", ".eth", "fóo.eth", "foo bar.eth"} {
+		if _, err := normalizeENSName(name); err == nil {
+			t.Errorf("normalizeENSName(%q) succeeded", name)
+		}
+	}
+}
+
+// testENS 是部署在模拟链上的 ENS 注册表和解析器，部署者拥有根节点。
+type testENS struct {
+	t        *testing.T
+	sim      *simulated.Backend
+	owner    *bind.TransactOpts
+	address  common.Address
+	registry *sol.ENSRegistry
+	resolver common.Address
+	records  *sol.ENSResolver
+}
+
+func deployTestENS(t *testing.T, sim *simulated.Backend, owner *bind.TransactOpts) *testENS {
+	t.Helper()
+	address, tx, registry, err := sol.DeployENSRegistry(owner, sim.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mined(t, sim, tx)
+	resolver, tx, records, err := sol.DeployENSResolver(owner, sim.Client(), address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mined(t, sim, tx)
+	return &testENS{t: t, sim: sim, owner: owner, address: address, registry: registry, resolver: resolver, records: records}
+}
+
+// claim 将名称的各级节点依次分配给部署者，返回名称的节点。
+func (e *testENS) claim(name string) common.Hash {
+	e.t.Helper()
+	var node common.Hash
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		label := crypto.Keccak256Hash([]byte(labels[i]))
+		tx, err := e.registry.SetSubnodeOwner(e.owner, node, label, e.owner.From)
+		if err != nil {
+			e.t.Fatal(err)
+		}
+		mined(e.t, e.sim, tx)
+		node = crypto.Keccak256Hash(node[:], label[:])
+	}
+	return node
+}
+
+// setResolver 为名称设置解析器。
+func (e *testENS) setResolver(name string) common.Hash {
+	e.t.Helper()
+	node := e.claim(name)
+	tx, err := e.registry.SetResolver(e.owner, node, e.resolver)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	mined(e.t, e.sim, tx)
+	return node
+}
+
+// setAddr 设置名称的地址记录。
+func (e *testENS) setAddr(name string, addr common.Address) {
+	e.t.Helper()
+	tx, err := e.records.SetAddr(e.owner, e.setResolver(name), addr)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	mined(e.t, e.sim, tx)
+}
+
+// setReverse 设置地址的反向解析名称。
+func (e *testENS) setReverse(addr common.Address, name string) {
+	e.t.Helper()
+	node := e.setResolver(strings.ToLower(addr.Hex()[2:]) + ".addr.reverse")
+	tx, err := e.records.SetName(e.owner, node, name)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	mined(e.t, e.sim, tx)
+}
+
+func TestResolveENS(t *testing.T) {
+	sim, cli, owner := newTestChain(t)
+	ens := deployTestENS(t, sim, owner)
+	alice := common.HexToAddress("0x2c7536E3605D9C16a7a3D7b1898e529396a65c23")
+	ens.setAddr("alice.eth", alice)
+	ens.setResolver("empty.eth")
+
+	c := newTestClient(t)
+	c.ensRegistry = ens.address
+	ctx := context.Background()
+	registry, err := c.ensRegistryContract(ctx, cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr, err := resolveENS(ctx, cli, registry, "Alice.ETH")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr != alice {
+		t.Fatalf("alice.eth resolved to %s, want %s", addr.Hex(), alice.Hex())
+	}
+
+	tests := map[string]string{
+		"nobody.eth": "is not registered or has no resolver",
+		"empty.eth":  "has no address record",
+		"bad..eth":   "empty label",
+	}
+	for name, want := range tests {
+		if _, err := resolveENS(ctx, cli, registry, name); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("resolveENS(%q) error = %v, want %q", name, err, want)
+		}
+	}
+
+	// 注册表地址上没有合约时报错。
+	c.ensRegistry = common.HexToAddress("0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e")
+	if _, err := c.ensRegistryContract(ctx, cli); err == nil || !strings.Contains(err.Error(), "no ENS registry") {
+		t.Fatalf("ensRegistryContract error = %v, want no ENS registry", err)
+	}
+}
+
+func TestReverseENS(t *testing.T) {
+	sim, cli, owner := newTestChain(t)
+	ens := deployTestENS(t, sim, owner)
+	var (
+		alice   = common.HexToAddress("0x2c7536E3605D9C16a7a3D7b1898e529396a65c23")
+		mallory = common.HexToAddress("0x703c4b2bD70c169f5717101CaeE543299Fc946C7")
+		nobody  = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		bob     = common.HexToAddress("0x2222222222222222222222222222222222222222")
+	)
+	ens.setAddr("alice.eth", alice)
+	ens.setReverse(alice, "Alice.eth")
+	// mallory 声明了 alice 的名称，但正向解析不指向 mallory。
+	ens.setReverse(mallory, "alice.eth")
+	// bob 的反向记录指向一个没有地址记录的名称。
+	ens.setReverse(bob, "ghost.eth")
+
+	c := newTestClient(t)
+	c.ensRegistry = ens.address
+	ctx := context.Background()
+	registry, err := c.ensRegistryContract(ctx, cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[common.Address]string{
+		alice:   "alice.eth",
+		mallory: "",
+		nobody:  "",
+		bob:     "",
+	}
+	for addr, want := range tests {
+		name, err := reverseENS(ctx, cli, registry, addr)
+		if err != nil {
+			t.Fatalf("reverseENS(%s): %v", addr.Hex(), err)
+		}
+		if name != want {
+			t.Errorf("reverseENS(%s) = %q, want %q", addr.Hex(), name, want)
+		}
+	}
+
+	names := c.newENSNames(ctx, cli)
+	if got, want := names.format(alice), alice.Hex()+" (alice.eth)"; got != want {
+		t.Errorf("format = %q, want %q", got, want)
+	}
+	if got := names.format(mallory); got != mallory.Hex() {
+		t.Errorf("format = %q, want %q", got, mallory.Hex())
+	}
+}
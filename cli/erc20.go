@@ -0,0 +1,199 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"go_wallet/registry"
+	"go_wallet/sol"
+	"math/big"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// isMissingMethod 判断合约调用错误是否表示合约没有实现该方法。
+// ERC20 的 name 和 decimals 是可选方法，未实现时调用会 revert 或返回空数据。
+func isMissingMethod(err error) bool {
+	return strings.Contains(err.Error(), "execution reverted") || strings.Contains(err.Error(), "empty string")
+}
+
+// discoverToken 通过合约调用获取 ERC20 代币的元数据。
+// 符号和总发行量通过 sol.Token 绑定查询，名称和精度通过通用的 sol.ERC20 绑定查询，
+// 合约未实现名称或精度时分别按空字符串和 0 位小数处理。
+func discoverToken(cli *ethclient.Client, address common.Address) (registry.Token, error) {
+	code, err := cli.CodeAt(context.Background(), address, nil)
+	if err != nil {
+		return registry.Token{}, err
+	}
+	if len(code) == 0 {
+		return registry.Token{}, fmt.Errorf("no contract code at %s", address.Hex())
+	}
+	token, err := sol.NewToken(address, cli)
+	if err != nil {
+		return registry.Token{}, err
+	}
+	erc20, err := sol.NewERC20(address, cli)
+	if err != nil {
+		return registry.Token{}, err
+	}
+	opts := &bind.CallOpts{}
+	if _, err := token.TotalSupply(opts); err != nil {
+		return registry.Token{}, fmt.Errorf("%s does not look like an ERC20 contract: %w", address.Hex(), err)
+	}
+	symbol, err := token.Symbol(opts)
+	if err != nil && !isMissingMethod(err) {
+		return registry.Token{}, fmt.Errorf("failed to get token symbol: %w", err)
+	}
+	name, err := erc20.Name(opts)
+	if err != nil && !isMissingMethod(err) {
+		return registry.Token{}, fmt.Errorf("failed to get token name: %w", err)
+	}
+	decimals, err := erc20.Decimals(opts)
+	if err != nil && !isMissingMethod(err) {
+		return registry.Token{}, fmt.Errorf("failed to get token decimals: %w", err)
+	}
+	return registry.Token{
+		Address:  address,
+		Symbol:   symbol,
+		Name:     name,
+		Decimals: decimals,
+	}, nil
+}
+
+// loadRegistry 加载数据目录下的代币注册表。
+func (c *Client) loadRegistry() (*registry.Registry, error) {
+	return registry.Load(filepath.Join(c.dataDir, registry.FileName))
+}
+
+// currentChainID 返回当前网络的链 ID；配置中没有链 ID 时从节点获取。
+func (c *Client) currentChainID() (*big.Int, error) {
+	if c.chainID != nil {
+		return c.chainID, nil
+	}
+	cli, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	cli.Close()
+	return c.chainID, nil
+}
+
+// resolveToken 将 -token 参数解析为代币信息。
+// 参数为空时使用配置的默认合约；参数可以是注册表中的符号或任意合约地址，
+// 不在注册表中的地址会通过合约调用获取元数据。
+func (c *Client) resolveToken(cli *ethclient.Client, symbolOrAddress string) (registry.Token, error) {
+	if symbolOrAddress == "" {
+		symbolOrAddress = c.tokenAddress.Hex()
+	}
+	reg, err := c.loadRegistry()
+	if err != nil {
+		return registry.Token{}, err
+	}
+	if token, ok := reg.Find(c.chainID, symbolOrAddress); ok {
+		return token, nil
+	}
+	if !common.IsHexAddress(symbolOrAddress) {
+		return registry.Token{}, fmt.Errorf("unknown token %q on chain %s, add it with addtoken", symbolOrAddress, c.chainID)
+	}
+	return discoverToken(cli, common.HexToAddress(symbolOrAddress))
+}
+
+// addtoken 获取合约的 ERC20 元数据并加入当前链的代币注册表。
+// 参数:
+//
+//	address - 代币合约地址。
+//	symbol - 覆盖合约返回的符号，合约未实现 symbol 时必须提供。
+//
+// 返回值:
+//
+//	如果获取元数据或保存注册表失败，则返回错误。
+func (c *Client) addtoken(address, symbol string) error {
+	if !common.IsHexAddress(address) {
+		return fmt.Errorf("invalid token address %q", address)
+	}
+	cli, err := c.dial()
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	token, err := discoverToken(cli, common.HexToAddress(address))
+	if err != nil {
+		return err
+	}
+	if symbol != "" {
+		token.Symbol = symbol
+	}
+	if token.Symbol == "" {
+		return fmt.Errorf("token %s has no symbol, please specify -symbol", address)
+	}
+	reg, err := c.loadRegistry()
+	if err != nil {
+		return err
+	}
+	if err := reg.Add(c.chainID, token); err != nil {
+		return err
+	}
+	if err := reg.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("Added %s at %s with %d decimals on chain %s\n", token.Symbol, token.Address.Hex(), token.Decimals, c.chainID)
+	return nil
+}
+
+// removetoken 从当前链的代币注册表中删除代币。
+// 参数:
+//
+//	symbolOrAddress - 代币符号或合约地址。
+//
+// 返回值:
+//
+//	如果代币不存在或保存注册表失败，则返回错误。
+func (c *Client) removetoken(symbolOrAddress string) error {
+	chainID, err := c.currentChainID()
+	if err != nil {
+		return err
+	}
+	reg, err := c.loadRegistry()
+	if err != nil {
+		return err
+	}
+	token, err := reg.Remove(chainID, symbolOrAddress)
+	if err != nil {
+		return err
+	}
+	if err := reg.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("Removed %s at %s from chain %s\n", token.Symbol, token.Address.Hex(), chainID)
+	return nil
+}
+
+// tokens 列出当前链的代币注册表。
+func (c *Client) tokens() error {
+	chainID, err := c.currentChainID()
+	if err != nil {
+		return err
+	}
+	reg, err := c.loadRegistry()
+	if err != nil {
+		return err
+	}
+	list := reg.List(chainID)
+	if len(list) == 0 {
+		fmt.Println("No tokens registered on chain", chainID)
+		return nil
+	}
+	fmt.Printf("%-10s %-8s %-42s %s\n", "SYMBOL", "DECIMALS", "ADDRESS", "NAME")
+	for _, t := range list {
+		mark := ""
+		if t.Address == c.tokenAddress {
+			mark = " (default)"
+		}
+		fmt.Printf("%-10s %-8d %-42s %s%s\n", t.Symbol, t.Decimals, t.Address.Hex(), t.Name, mark)
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"go_wallet/history"
+	"go_wallet/registry"
+	"go_wallet/units"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// exportRow 是导出报表中的一行，金额均按资产精度格式化。
+type exportRow struct {
+	Time         string `json:"time"` // RFC3339，UTC
+	Block        uint64 `json:"block"`
+	TxHash       string `json:"txHash"`
+	Asset        string `json:"asset"`           // ETH 或代币符号
+	Token        string `json:"token,omitempty"` // 代币合约地址
+	Direction    string `json:"direction"`       // IN、OUT 或 SELF
+	Counterparty string `json:"counterparty"`
+	Amount       string `json:"amount"`
+	Fee          string `json:"fee"` // 账户作为发送方支付的手续费（ETH）
+	Status       string `json:"status"`
+}
+
+// exportHeader 是 CSV 报表的表头，与 exportRow 的字段顺序一致。
+var exportHeader = []string{"time", "block", "tx_hash", "asset", "token", "direction", "counterparty", "amount", "fee_eth", "status"}
+
+// blockByTime 通过对区块时间戳二分查找，返回第一个时间戳不早于 t 的区块号。
+// 所有区块都早于 t 时返回 head+1。
+func blockByTime(ctx context.Context, cli *ethclient.Client, t time.Time, head uint64) (uint64, error) {
+	target := uint64(t.Unix())
+	lo, hi := uint64(0), head+1
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		header, err := cli.HeaderByNumber(ctx, new(big.Int).SetUint64(mid))
+		if err != nil {
+			return 0, fmt.Errorf("failed to get block %d: %w", mid, err)
+		}
+		if header.Time >= target {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo, nil
+}
+
+// scanRecords 直接从节点读取区块范围内账户的以太币交易和代币转账，用于没有本地索引的情况。
+func (c *Client) scanRecords(ctx context.Context, cli *ethclient.Client, tokens []registry.Token, account common.Address, from, to uint64) ([]history.Record, error) {
+	fmt.Fprintf(os.Stderr, "Scanning blocks %d-%d ...\n", from, to)
+	isAccount := map[common.Address]bool{account: true}
+	signer := types.LatestSignerForChainID(c.chainID)
+	var records []history.Record
+	times := make(map[uint64]uint64)
+	for n := from; n <= to; n++ {
+		block, err := cli.BlockByNumber(ctx, new(big.Int).SetUint64(n))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get block %d: %w", n, err)
+		}
+		times[n] = block.Time()
+		blockRecs, err := etherRecords(ctx, cli, signer, block, isAccount)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, blockRecs...)
+	}
+	for _, t := range tokens {
+		tokenRecs, err := tokenRecords(ctx, cli, t, []common.Address{account}, from, to, times)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, tokenRecs...)
+	}
+	// 以太币交易和代币转账按区块顺序合并，同一区块内以太币交易在前。
+	sortRecords(records)
+	return records, nil
+}
+
+// export 导出账户在指定日期范围内的以太币和代币收支明细，用于对账。
+// 日期范围通过二分查找区块时间戳转换为区块范围。账户已建立本地索引时从索引查询，否则扫描链上数据。
+// 参数:
+//
+//	account - 账户地址。
+//	from - 开始日期（包含），为空时从创世区块开始。
+//	to - 结束日期（包含），为空时到最新区块。
+//	format - csv 或 json。
+//	asset - eth、代币符号或代币地址，为空时导出全部资产。
+//	out - 输出文件，为空时输出到标准输出。
+//
+// 返回值:
+//
+//	如果查询或写入过程中发生错误，则返回错误。
+func (c *Client) export(account, from, to, format, asset, out string) error {
+	if !common.IsHexAddress(account) {
+		return fmt.Errorf("invalid address %q", account)
+	}
+	format = strings.ToLower(format)
+	if format != "csv" && format != "json" {
+		return fmt.Errorf("unknown format %q, expected csv or json", format)
+	}
+	fromTime, err := parseDate(from, false)
+	if err != nil {
+		return err
+	}
+	toTime, err := parseDate(to, true)
+	if err != nil {
+		return err
+	}
+
+	cli, err := c.dial()
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	ctx := context.Background()
+	head, err := cli.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+	// 开始日期晚于最新区块或结束日期早于创世区块时，范围为空。
+	fromBlock, toBlock, empty := uint64(0), head, false
+	if !fromTime.IsZero() {
+		if fromBlock, err = blockByTime(ctx, cli, fromTime, head); err != nil {
+			return err
+		}
+	}
+	if !toTime.IsZero() {
+		end, err := blockByTime(ctx, cli, toTime, head)
+		if err != nil {
+			return err
+		}
+		if end == 0 {
+			empty = true
+		} else {
+			toBlock = end - 1
+		}
+	}
+
+	tokens, err := c.trackedTokens(cli)
+	if err != nil {
+		return err
+	}
+	filter := history.Filter{FromBlock: fromBlock, ToBlock: toBlock}
+	if err := assetFilter(asset, tokens, &filter); err != nil {
+		return err
+	}
+	addr := common.HexToAddress(account)
+	var records []history.Record
+	if !empty && fromBlock <= toBlock {
+		var indexed bool
+		records, indexed, err = c.queryHistory(ctx, cli, tokens, addr, filter)
+		if err != nil {
+			return err
+		}
+		if !indexed {
+			scanned, err := c.scanRecords(ctx, cli, tokens, addr, fromBlock, toBlock)
+			if err != nil {
+				return err
+			}
+			filter.Account = &addr
+			for _, r := range scanned {
+				if filter.Match(r) {
+					records = append(records, r)
+				}
+			}
+		}
+	}
+
+	rows, err := exportRows(ctx, cli, records, tokens, addr)
+	if err != nil {
+		return err
+	}
+	var content string
+	if format == "json" {
+		b, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			return err
+		}
+		content = string(b)
+	} else {
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write(exportHeader)
+		for _, r := range rows {
+			w.Write([]string{r.Time, strconv.FormatUint(r.Block, 10), r.TxHash, r.Asset, r.Token, r.Direction, r.Counterparty, r.Amount, r.Fee, r.Status})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+		content = strings.TrimSuffix(buf.String(), "\n")
+	}
+	if err := writeOutput(out, content); err != nil {
+		return err
+	}
+	if out != "" {
+		fmt.Printf("Exported %d record(s) of %s in blocks %d-%d to %s\n", len(rows), addr.Hex(), fromBlock, toBlock, out)
+	}
+	return nil
+}
+
+// exportRows 将记录转换为报表行。手续费只计在账户发送的以太币交易上，
+// 代币转账的手续费已经包含在同一笔交易对应的以太币记录中，避免重复计算。
+func exportRows(ctx context.Context, cli *ethclient.Client, records []history.Record, tokens []registry.Token, account common.Address) ([]exportRow, error) {
+	tokenMap := make(map[common.Address]registry.Token, len(tokens))
+	for _, t := range tokens {
+		tokenMap[t.Address] = t
+	}
+	isAccount := func(addr common.Address) bool { return addr == account }
+	rows := make([]exportRow, 0, len(records))
+	for _, r := range records {
+		row := exportRow{
+			Time:      time.Unix(int64(r.Time), 0).UTC().Format(time.RFC3339),
+			Block:     r.BlockNumber,
+			TxHash:    r.TxHash.Hex(),
+			Asset:     "ETH",
+			Direction: recordDirection(r, isAccount),
+			Status:    "success",
+		}
+		decimals := units.EtherDecimals
+		if r.Kind == history.KindToken {
+			t := tokenMap[r.Token]
+			row.Asset, row.Token, decimals = t.Symbol, r.Token.Hex(), int(t.Decimals)
+			if row.Asset == "" {
+				row.Asset = r.Token.Hex()
+			}
+		}
+		switch row.Direction {
+		case "OUT":
+			row.Counterparty = r.To.Hex()
+		case "IN":
+			row.Counterparty = r.From.Hex()
+		default:
+			row.Counterparty = account.Hex()
+		}
+		row.Amount = units.FormatUnits(r.Value, decimals)
+		if r.Failed {
+			row.Status = "failed"
+		}
+		if r.Kind == history.KindEther && r.From == account {
+			fee := r.Fee
+			if fee == nil {
+				receipt, err := cli.TransactionReceipt(ctx, r.TxHash)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get receipt of %s: %w", r.TxHash.Hex(), err)
+				}
+				fee = receiptFee(receipt)
+			}
+			row.Fee = units.FormatEther(fee)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
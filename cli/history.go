@@ -0,0 +1,617 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_wallet/hdwallet"
+	"go_wallet/history"
+	"go_wallet/registry"
+	"go_wallet/sol"
+	"go_wallet/units"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const (
+	historyBatchSize    = 100   // 每次提交到数据库的区块数
+	historyReorgWindow  = 128   // 保存区块哈希的数量，超过该深度的链重组无法自动回滚
+	historyRecentBlocks = 50000 // 未指定起始区块时，只索引最近的区块数（主网约一周）
+)
+
+// openHistory 打开数据目录下的历史记录数据库。
+func (c *Client) openHistory() (*history.Store, error) {
+	return history.Open(filepath.Join(c.dataDir, history.FileName))
+}
+
+// trackedTokens 返回需要索引的代币：配置的默认代币、注册表中的代币以及额外指定的代币。
+// 无法获取元数据的默认代币会被跳过。
+func (c *Client) trackedTokens(cli *ethclient.Client, extra ...registry.Token) ([]registry.Token, error) {
+	reg, err := c.loadRegistry()
+	if err != nil {
+		return nil, err
+	}
+	tokens := append(reg.List(c.chainID), extra...)
+	if c.tokenAddress != (common.Address{}) {
+		if token, err := c.resolveToken(cli, ""); err == nil {
+			tokens = append(tokens, token)
+		} else {
+			fmt.Fprintln(os.Stderr, "Skipping default token contract:", err)
+		}
+	}
+	seen := make(map[common.Address]bool)
+	unique := tokens[:0]
+	for _, t := range tokens {
+		if !seen[t.Address] {
+			seen[t.Address] = true
+			unique = append(unique, t)
+		}
+	}
+	return unique, nil
+}
+
+// addedAccounts 返回 b 中不在 a 里的账户。
+func addedAccounts(a, b []common.Address) []common.Address {
+	set := make(map[common.Address]bool, len(a))
+	for _, addr := range a {
+		set[addr] = true
+	}
+	var added []common.Address
+	for _, addr := range b {
+		if !set[addr] {
+			added = append(added, addr)
+		}
+	}
+	return added
+}
+
+// historyStart 返回重新创建索引时的起始区块。
+// startBlock 为负数时从最近 historyRecentBlocks 个区块开始，但不早于 floor。
+func historyStart(ctx context.Context, cli *ethclient.Client, startBlock int64, floor uint64) (uint64, error) {
+	if startBlock >= 0 {
+		return uint64(startBlock), nil
+	}
+	head, err := cli.BlockNumber(ctx)
+	if err != nil {
+		return 0, err
+	}
+	start := uint64(0)
+	if head > historyRecentBlocks {
+		start = head - historyRecentBlocks
+	}
+	return max(start, floor), nil
+}
+
+// containsAccount 判断账户列表中是否包含指定地址。
+func containsAccount(accounts []common.Address, addr common.Address) bool {
+	for _, a := range accounts {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// syncHistory 将数据目录中所有账户的以太币交易和代币转账增量索引到本地数据库，进度信息输出到标准错误。
+// 开始索引前会检查最近索引的区块哈希，发生链重组时删除分叉之后的记录并重新索引；
+// 数据目录中新增账户时会重建索引，移除账户只更新账户列表。
+// 参数:
+//
+//	store - 历史记录数据库。
+//	tokens - 需要索引的代币，新加入的代币会从索引起点开始补齐。
+//	startBlock - 创建或重建索引时的起始区块，为负数时只索引最近 historyRecentBlocks 个区块。
+//
+// 返回值:
+//
+//	*history.Meta - 索引进度。
+//	error - 如果索引过程中发生错误，则返回错误；已经提交的进度会保留。
+func (c *Client) syncHistory(ctx context.Context, cli *ethclient.Client, store *history.Store, tokens []registry.Token, startBlock int64) (*history.Meta, error) {
+	accounts, err := hdwallet.ListAccounts(c.dataDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(accounts) == 0 {
+		return nil, errors.New("no accounts in the keystore")
+	}
+	meta, err := store.Meta(c.chainID)
+	if err != nil {
+		return nil, err
+	}
+	if meta != nil {
+		if added := addedAccounts(meta.Accounts, accounts); len(added) > 0 {
+			// 新账户在索引范围内的历史需要重新扫描区块，默认不早于原来的起点，也不超过最近的区块数。
+			start, err := historyStart(ctx, cli, startBlock, meta.Start)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(os.Stderr, "%d account(s) added to the keystore, rebuilding the history index from block %d\n", len(added), start)
+			if err := store.Reset(c.chainID); err != nil {
+				return nil, err
+			}
+			meta, startBlock = nil, int64(start)
+		} else if len(meta.Accounts) != len(accounts) {
+			// 移除账户不影响其他账户的记录，已有的记录保留。
+			meta.Accounts = accounts
+			if err := store.Commit(c.chainID, meta, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if meta == nil {
+		start, err := historyStart(ctx, cli, startBlock, 0)
+		if err != nil {
+			return nil, err
+		}
+		if startBlock < 0 && start > 0 {
+			fmt.Fprintf(os.Stderr, "Indexing the last %d blocks from block %d, use -index-from to start from an earlier block\n", historyRecentBlocks, start)
+		}
+		meta = &history.Meta{
+			Start:    start,
+			Next:     start,
+			Accounts: accounts,
+			Tokens:   make(map[common.Address]uint64),
+			Hashes:   make(map[uint64]common.Hash),
+		}
+	}
+
+	if err := c.rewindHistory(ctx, cli, store, meta); err != nil {
+		return nil, err
+	}
+
+	// 新加入的代币先补齐到当前的索引进度。
+	for _, t := range tokens {
+		next, ok := meta.Tokens[t.Address]
+		if !ok {
+			next = meta.Start
+		}
+		if next < meta.Next {
+			records, err := tokenRecords(ctx, cli, t, accounts, next, meta.Next-1, nil)
+			if err != nil {
+				return nil, err
+			}
+			meta.Tokens[t.Address] = meta.Next
+			if err := store.Commit(c.chainID, meta, records); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	head, err := cli.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if meta.Next <= head {
+		fmt.Fprintf(os.Stderr, "Indexing blocks %d-%d ...\n", meta.Next, head)
+	}
+	isAccount := make(map[common.Address]bool, len(accounts))
+	for _, a := range accounts {
+		isAccount[a] = true
+	}
+	signer := types.LatestSignerForChainID(c.chainID)
+	for meta.Next <= head {
+		end := min(meta.Next+historyBatchSize-1, head)
+		var records []history.Record
+		times := make(map[uint64]uint64)
+		for n := meta.Next; n <= end; n++ {
+			block, err := cli.BlockByNumber(ctx, new(big.Int).SetUint64(n))
+			if err != nil {
+				return nil, fmt.Errorf("failed to get block %d: %w", n, err)
+			}
+			times[n] = block.Time()
+			meta.Hashes[n] = block.Hash()
+			blockRecs, err := etherRecords(ctx, cli, signer, block, isAccount)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, blockRecs...)
+		}
+		for _, t := range tokens {
+			tokenRecs, err := tokenRecords(ctx, cli, t, accounts, meta.Next, end, times)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, tokenRecs...)
+			meta.Tokens[t.Address] = end + 1
+		}
+		meta.Next = end + 1
+		for n := range meta.Hashes {
+			if n+historyReorgWindow < meta.Next {
+				delete(meta.Hashes, n)
+			}
+		}
+		if err := store.Commit(c.chainID, meta, records); err != nil {
+			return nil, err
+		}
+	}
+	return meta, nil
+}
+
+// etherRecords 返回区块中发送方或接收方是账户的交易，并通过回执获取执行状态和手续费。
+func etherRecords(ctx context.Context, cli *ethclient.Client, signer types.Signer, block *types.Block, isAccount map[common.Address]bool) ([]history.Record, error) {
+	var records []history.Record
+	for i, tx := range block.Transactions() {
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			continue
+		}
+		var to common.Address
+		if tx.To() != nil {
+			to = *tx.To()
+		}
+		if !isAccount[from] && !isAccount[to] {
+			continue
+		}
+		receipt, err := cli.TransactionReceipt(ctx, tx.Hash())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get receipt of %s: %w", tx.Hash().Hex(), err)
+		}
+		records = append(records, history.Record{
+			Kind:        history.KindEther,
+			BlockNumber: block.NumberU64(),
+			BlockHash:   block.Hash(),
+			Time:        block.Time(),
+			TxHash:      tx.Hash(),
+			Index:       uint(i),
+			From:        from,
+			To:          to,
+			Value:       tx.Value(),
+			Fee:         receiptFee(receipt),
+			Failed:      receipt.Status == types.ReceiptStatusFailed,
+		})
+	}
+	return records, nil
+}
+
+// receiptFee 根据回执计算交易支付的手续费。
+func receiptFee(receipt *types.Receipt) *big.Int {
+	if receipt.EffectiveGasPrice == nil {
+		return nil
+	}
+	return new(big.Int).Mul(receipt.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+}
+
+// rewindHistory 检查最近索引的区块是否仍在主链上，发生链重组时回滚到共同祖先区块。
+func (c *Client) rewindHistory(ctx context.Context, cli *ethclient.Client, store *history.Store, meta *history.Meta) error {
+	numbers := make([]uint64, 0, len(meta.Hashes))
+	for n := range meta.Hashes {
+		numbers = append(numbers, n)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] > numbers[j] })
+	for i, n := range numbers {
+		header, err := cli.HeaderByNumber(ctx, new(big.Int).SetUint64(n))
+		if err == nil && header.Hash() == meta.Hashes[n] {
+			if i == 0 {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "Chain reorganization detected, rolling back history to block %d\n", n)
+			return c.rewindTo(store, meta, n)
+		}
+	}
+	if len(numbers) == 0 {
+		return nil
+	}
+	// 所有保存的区块都已不在主链上，从保存的最早区块之前重新索引。
+	oldest := numbers[len(numbers)-1]
+	if oldest <= meta.Start {
+		fmt.Fprintln(os.Stderr, "Chain reorganization deeper than the history index, rebuilding")
+		meta.Next = meta.Start
+		meta.Tokens = make(map[common.Address]uint64)
+		meta.Hashes = make(map[uint64]common.Hash)
+		if err := store.Reset(c.chainID); err != nil {
+			return err
+		}
+		return store.Commit(c.chainID, meta, nil)
+	}
+	fmt.Fprintf(os.Stderr, "Chain reorganization deeper than %d blocks, rolling back history to block %d\n", historyReorgWindow, oldest-1)
+	return c.rewindTo(store, meta, oldest-1)
+}
+
+// rewindTo 删除 ancestor 之后的记录，并将索引进度回退到 ancestor 之后。
+func (c *Client) rewindTo(store *history.Store, meta *history.Meta, ancestor uint64) error {
+	meta.Next = min(meta.Next, ancestor+1)
+	for addr, next := range meta.Tokens {
+		meta.Tokens[addr] = min(next, ancestor+1)
+	}
+	for n := range meta.Hashes {
+		if n > ancestor {
+			delete(meta.Hashes, n)
+		}
+	}
+	return store.Rewind(c.chainID, meta, ancestor)
+}
+
+// tokenRecords 分页查询区块范围内账户相关的代币转账，并转换为按链上顺序排列的历史记录。
+// 自己转给自己的事件只保留一条，times 中没有的区块时间戳会从节点查询。
+func tokenRecords(ctx context.Context, cli *ethclient.Client, t registry.Token, accounts []common.Address, from, to uint64, times map[uint64]uint64) ([]history.Record, error) {
+	token, err := sol.NewToken(t.Address, cli)
+	if err != nil {
+		return nil, err
+	}
+	var events []*sol.TokenTransfer
+	seen := make(map[string]bool)
+	err = scanRanges(from, to, func(a, b uint64) error {
+		batch, err := filterTransferRange(ctx, token, accounts, a, b)
+		if err != nil {
+			return err
+		}
+		for _, ev := range batch {
+			// 自己转给自己的事件会同时出现在两个查询结果中。
+			key := fmt.Sprintf("%s:%d", ev.Raw.TxHash.Hex(), ev.Raw.Index)
+			if ev.Raw.Removed || seen[key] {
+				continue
+			}
+			seen[key] = true
+			events = append(events, ev)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to index %s transfers: %w", t.Symbol, err)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Raw.BlockNumber != events[j].Raw.BlockNumber {
+			return events[i].Raw.BlockNumber < events[j].Raw.BlockNumber
+		}
+		return events[i].Raw.Index < events[j].Raw.Index
+	})
+	var missing []uint64
+	for _, ev := range events {
+		if _, ok := times[ev.Raw.BlockNumber]; !ok {
+			missing = append(missing, ev.Raw.BlockNumber)
+		}
+	}
+	fetched, err := blockTimes(ctx, cli, missing)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]history.Record, 0, len(events))
+	for _, ev := range events {
+		ts, ok := times[ev.Raw.BlockNumber]
+		if !ok {
+			ts = uint64(fetched[ev.Raw.BlockNumber].Unix())
+		}
+		records = append(records, history.Record{
+			Kind:        history.KindToken,
+			Token:       t.Address,
+			BlockNumber: ev.Raw.BlockNumber,
+			BlockHash:   ev.Raw.BlockHash,
+			Time:        ts,
+			TxHash:      ev.Raw.TxHash,
+			Index:       ev.Raw.Index,
+			From:        ev.From,
+			To:          ev.To,
+			Value:       ev.Value,
+		})
+	}
+	return records, nil
+}
+
+// queryHistory 增量索引后从本地数据库查询 who 的记录。
+// who 不是数据目录中的账户、尚未建立索引或者索引不覆盖 filter.FromBlock 时返回 false，由调用方扫描链上数据。
+func (c *Client) queryHistory(ctx context.Context, cli *ethclient.Client, tokens []registry.Token, who common.Address, filter history.Filter) ([]history.Record, bool, error) {
+	accounts, err := hdwallet.ListAccounts(c.dataDir)
+	if err != nil || !containsAccount(accounts, who) {
+		return nil, false, nil
+	}
+	store, err := c.openHistory()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "History database unavailable, scanning the chain:", err)
+		return nil, false, nil
+	}
+	defer store.Close()
+	meta, err := store.Meta(c.chainID)
+	if err != nil {
+		return nil, false, err
+	}
+	if meta == nil || filter.FromBlock < meta.Start {
+		return nil, false, nil
+	}
+	if meta, err = c.syncHistory(ctx, cli, store, tokens, -1); err != nil {
+		return nil, false, err
+	}
+	if filter.FromBlock < meta.Start {
+		return nil, false, nil
+	}
+	filter.Account = &who
+	records, err := store.Query(c.chainID, filter)
+	if err != nil {
+		return nil, false, err
+	}
+	return records, true, nil
+}
+
+// assetFilter 根据 eth、代币符号或代币地址设置查询条件。
+func assetFilter(asset string, tokens []registry.Token, filter *history.Filter) error {
+	switch {
+	case asset == "":
+	case strings.EqualFold(asset, "eth"):
+		filter.Kind = history.KindEther
+	case common.IsHexAddress(asset):
+		addr := common.HexToAddress(asset)
+		filter.Token = &addr
+	default:
+		for _, t := range tokens {
+			if strings.EqualFold(t.Symbol, asset) {
+				addr := t.Address
+				filter.Token = &addr
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown token %q", asset)
+	}
+	return nil
+}
+
+// sortRecords 按链上顺序排列记录，同一区块内以太币交易在代币转账之前，与数据库中的顺序一致。
+func sortRecords(records []history.Record) {
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if a.BlockNumber != b.BlockNumber {
+			return a.BlockNumber < b.BlockNumber
+		}
+		if a.Kind != b.Kind {
+			return a.Kind == history.KindEther
+		}
+		return a.Index < b.Index
+	})
+}
+
+// parseDate 解析 2006-01-02 或 RFC3339 格式的时间，只有日期时按 UTC 零点处理。
+// endOfDay 为 true 且只有日期时，返回下一天的零点，便于作为不包含的结束时间。
+func parseDate(s string, endOfDay bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD or RFC3339", s)
+	}
+	return t, nil
+}
+
+// recordDirection 返回记录相对于一组账户的方向。
+func recordDirection(r history.Record, isAccount func(common.Address) bool) string {
+	switch in, out := isAccount(r.To), isAccount(r.From); {
+	case in && out:
+		return "SELF"
+	case out:
+		return "OUT"
+	default:
+		return "IN"
+	}
+}
+
+// printRecords 以表格形式打印历史记录。
+func printRecords(records []history.Record, tokens map[common.Address]registry.Token, isAccount func(common.Address) bool) {
+	fmt.Printf("%-8s %-19s %-8s %-4s %-42s %-42s %24s %s\n", "BLOCK", "TIME (UTC)", "ASSET", "DIR", "FROM", "TO", "VALUE", "TX HASH")
+	for _, r := range records {
+		asset, decimals := "ETH", units.EtherDecimals
+		if r.Kind == history.KindToken {
+			t, ok := tokens[r.Token]
+			asset, decimals = t.Symbol, int(t.Decimals)
+			if !ok || asset == "" {
+				asset = r.Token.Hex()[:8]
+			}
+		}
+		value := units.FormatUnits(r.Value, decimals)
+		if r.Failed {
+			value += " (failed)"
+		}
+		fmt.Printf("%-8d %-19s %-8s %-4s %-42s %-42s %24s %s\n", r.BlockNumber, time.Unix(int64(r.Time), 0).UTC().Format(time.DateTime),
+			asset, recordDirection(r, isAccount), r.From.Hex(), r.To.Hex(), value, r.TxHash.Hex())
+	}
+}
+
+// history 增量索引后从本地数据库查询交易和代币转账记录。
+// 参数:
+//
+//	account - 账户地址，为空时查询所有账户。
+//	asset - eth、代币符号或代币地址，为空时不限制。
+//	direction - in 或 out，为空时不限制。
+//	from - 开始日期（包含）。
+//	to - 结束日期（包含）。
+//	indexFrom - 创建或重建索引时的起始区块，为负数时只索引最近的区块，为 0 时从创世区块开始。
+//	offline - 为 true 时不连接节点，直接查询已有的索引。
+//
+// 返回值:
+//
+//	如果索引或查询过程中发生错误，则返回错误。
+func (c *Client) history(account, asset, direction, from, to string, indexFrom int64, offline bool) error {
+	filter := history.Filter{Direction: strings.ToLower(direction)}
+	if filter.Direction != "" && filter.Direction != "in" && filter.Direction != "out" {
+		return fmt.Errorf("invalid direction %q, expected in or out", direction)
+	}
+	if account != "" {
+		if !common.IsHexAddress(account) {
+			return fmt.Errorf("invalid address %q", account)
+		}
+		addr := common.HexToAddress(account)
+		filter.Account = &addr
+	} else if filter.Direction != "" {
+		return errors.New("-direction requires -account")
+	}
+	var err error
+	if filter.FromTime, err = parseDate(from, false); err != nil {
+		return err
+	}
+	if filter.ToTime, err = parseDate(to, true); err != nil {
+		return err
+	}
+
+	store, err := c.openHistory()
+	if err != nil {
+		return fmt.Errorf("failed to open history database: %w", err)
+	}
+	defer store.Close()
+
+	var tokens []registry.Token
+	if offline {
+		if c.chainID == nil {
+			return errors.New("-offline requires a configured chain id")
+		}
+		reg, err := c.loadRegistry()
+		if err != nil {
+			return err
+		}
+		tokens = reg.List(c.chainID)
+	} else {
+		cli, err := c.dial()
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+		if tokens, err = c.trackedTokens(cli); err != nil {
+			return err
+		}
+		if _, err := c.syncHistory(context.Background(), cli, store, tokens, indexFrom); err != nil {
+			return err
+		}
+	}
+
+	tokenMap := make(map[common.Address]registry.Token, len(tokens))
+	for _, t := range tokens {
+		tokenMap[t.Address] = t
+	}
+	if err := assetFilter(asset, tokens, &filter); err != nil {
+		return err
+	}
+
+	records, err := store.Query(c.chainID, filter)
+	if err != nil {
+		return err
+	}
+	meta, err := store.Meta(c.chainID)
+	if err != nil {
+		return err
+	}
+	if meta == nil {
+		return errors.New("history is not indexed yet, run history without -offline first")
+	}
+	isAccount := func(addr common.Address) bool {
+		if filter.Account != nil {
+			return addr == *filter.Account
+		}
+		return containsAccount(meta.Accounts, addr)
+	}
+	if meta.Next > meta.Start {
+		fmt.Printf("%d record(s), indexed blocks %d-%d\n", len(records), meta.Start, meta.Next-1)
+	}
+	if len(records) > 0 {
+		printRecords(records, tokenMap, isAccount)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"go_wallet/hdkeystore"
+	"go_wallet/hdwallet"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// readMessage 从命令行参数或文件中读取要签名的消息，文件优先。
+func readMessage(msg, file string) ([]byte, error) {
+	if file != "" {
+		return os.ReadFile(file)
+	}
+	if msg == "" {
+		return nil, errors.New("no message given, please specify -msg or -file")
+	}
+	return []byte(msg), nil
+}
+
+// signmessage 使用指定账户按照 EIP-191 (personal_sign) 规则对消息签名并打印签名。
+// 参数:
+//
+//	from - 签名账户地址。
+//	msg - 消息文本，与 file 二选一。
+//	file - 消息文件。
+//
+// 返回值:
+//
+//	string - 十六进制编码的签名。
+//	error - 如果签名过程中发生错误，则返回错误。
+func (c *Client) signmessage(from, msg, file string) (string, error) {
+	data, err := readMessage(msg, file)
+	if err != nil {
+		return "", err
+	}
+	fmt.Printf("Message to sign (%d bytes): %q\n", len(data), string(data))
+	w, err := hdwallet.LoadWallet(from, c.dataDir)
+	if err != nil {
+		return "", err
+	}
+	sig, err := w.SignMessage(data)
+	if err != nil {
+		return "", err
+	}
+	return hexutil.Encode(sig), nil
+}
+
+// verifymessage 从 EIP-191 (personal_sign) 签名中恢复签名者地址，
+// 如果给出了 address，则同时检查签名者是否匹配。
+// 参数:
+//
+//	address - 期望的签名者地址，可以为空。
+//	msg - 消息文本，与 file 二选一。
+//	file - 消息文件。
+//	sig - 十六进制编码的签名。
+//
+// 返回值:
+//
+//	如果签名无效或签名者不匹配，则返回错误。
+func (c *Client) verifymessage(address, msg, file, sig string) error {
+	data, err := readMessage(msg, file)
+	if err != nil {
+		return err
+	}
+	sigBytes, err := hexutil.Decode(sig)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	signer, err := hdkeystore.RecoverMessageSigner(data, sigBytes)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Signer:", signer.Hex())
+	if address == "" {
+		return nil
+	}
+	if signer != common.HexToAddress(address) {
+		return fmt.Errorf("signature is not from %s", address)
+	}
+	fmt.Println("Signature is valid for", signer.Hex())
+	return nil
+}
@@ -0,0 +1,326 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_wallet/hdwallet"
+	"go_wallet/units"
+	"math/big"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// unsignedTx 是离线签名流程中在联网机器和离线机器之间传递的未签名交易。
+type unsignedTx struct {
+	From     common.Address  `json:"from"`
+	ChainID  *hexutil.Big    `json:"chainId"`
+	Nonce    hexutil.Uint64  `json:"nonce"`
+	To       *common.Address `json:"to"`
+	Value    *hexutil.Big    `json:"value"`
+	Gas      hexutil.Uint64  `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Data     hexutil.Bytes   `json:"data"`
+}
+
+// eip155Payload 是 EIP-155 交易签名时的 RLP 结构，包含链 ID，可以独立于 JSON 传递。
+type eip155Payload struct {
+	Nonce    uint64
+	GasPrice *big.Int
+	Gas      uint64
+	To       *common.Address `rlp:"nil"`
+	Value    *big.Int
+	Data     []byte
+	ChainID  *big.Int
+	R, S     uint
+}
+
+// transaction 将未签名交易转换为 types.Transaction。
+func (u *unsignedTx) transaction() *types.Transaction {
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    uint64(u.Nonce),
+		GasPrice: u.GasPrice.ToInt(),
+		Gas:      uint64(u.Gas),
+		To:       u.To,
+		Value:    u.Value.ToInt(),
+		Data:     u.Data,
+	})
+}
+
+// encodeRLP 将未签名交易编码为 EIP-155 签名负载的十六进制 RLP。
+func (u *unsignedTx) encodeRLP() (string, error) {
+	b, err := rlp.EncodeToBytes(&eip155Payload{
+		Nonce:    uint64(u.Nonce),
+		GasPrice: u.GasPrice.ToInt(),
+		Gas:      uint64(u.Gas),
+		To:       u.To,
+		Value:    u.Value.ToInt(),
+		Data:     u.Data,
+		ChainID:  u.ChainID.ToInt(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return hexutil.Encode(b), nil
+}
+
+// readUnsignedTx 从文件中读取未签名交易。
+func readUnsignedTx(file string) (*unsignedTx, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return parseUnsignedTx(strings.TrimSpace(string(content)))
+}
+
+// parseUnsignedTx 解析未签名交易，支持 JSON 和十六进制 RLP 两种格式。
+// RLP 格式不包含发送方地址，此时 from 为空。
+func parseUnsignedTx(text string) (*unsignedTx, error) {
+	if strings.HasPrefix(text, "{") {
+		var u unsignedTx
+		if err := json.Unmarshal([]byte(text), &u); err != nil {
+			return nil, fmt.Errorf("invalid unsigned transaction json: %w", err)
+		}
+		if u.ChainID == nil || u.Value == nil || u.GasPrice == nil {
+			return nil, errors.New("unsigned transaction json is missing chainId, value or gasPrice")
+		}
+		return &u, nil
+	}
+	raw, err := hexutil.Decode(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid unsigned transaction rlp: %w", err)
+	}
+	var p eip155Payload
+	if err := rlp.DecodeBytes(raw, &p); err != nil {
+		return nil, fmt.Errorf("invalid unsigned transaction rlp: %w", err)
+	}
+	return &unsignedTx{
+		ChainID:  (*hexutil.Big)(p.ChainID),
+		Nonce:    hexutil.Uint64(p.Nonce),
+		To:       p.To,
+		Value:    (*hexutil.Big)(p.Value),
+		Gas:      hexutil.Uint64(p.Gas),
+		GasPrice: (*hexutil.Big)(p.GasPrice),
+		Data:     p.Data,
+	}, nil
+}
+
+// writeOutput 将内容写入文件；如果文件名为空，则输出到标准输出。
+func writeOutput(file, content string) error {
+	if file == "" {
+		fmt.Println(content)
+		return nil
+	}
+	return os.WriteFile(file, []byte(content+"\n"), 0600)
+}
+
+// confirm 打印提示并从标准输入读取确认，只有输入 y 或 yes 时返回 true。
+// 逐字节读取一行，避免缓冲读取吞掉之后密码输入的内容。
+func confirm(prompt string) bool {
+	fmt.Print(prompt, " [y/N]: ")
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := os.Stdin.Read(b)
+		if n == 0 || err != nil || b[0] == '\n' {
+			break
+		}
+		line = append(line, b[0])
+	}
+	answer := strings.ToLower(strings.TrimSpace(string(line)))
+	return answer == "y" || answer == "yes"
+}
+
+// printUnsignedTx 打印未签名交易的可读摘要，供操作员在签名前核对。
+func printUnsignedTx(u *unsignedTx) {
+	tx := u.transaction()
+	fmt.Println("========== Transaction to sign ==========")
+	if u.From != (common.Address{}) {
+		fmt.Println("From:      ", u.From.Hex())
+	}
+	if u.To != nil {
+		fmt.Println("To:        ", u.To.Hex())
+	} else {
+		fmt.Println("To:         (contract creation)")
+	}
+	fmt.Println("Value:     ", units.FormatEther(tx.Value()), "ETH (", tx.Value(), "wei )")
+	fmt.Println("Chain ID:  ", u.ChainID.ToInt())
+	fmt.Println("Nonce:     ", tx.Nonce())
+	fmt.Println("Gas limit: ", tx.Gas())
+	fmt.Println("Gas price: ", units.FormatUnits(tx.GasPrice(), 9), "gwei")
+	fee := new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(tx.Gas()))
+	fmt.Println("Max fee:   ", units.FormatEther(fee), "ETH")
+	if len(u.Data) > 0 {
+		fmt.Println("Data:      ", hexutil.Encode(u.Data))
+		if utf8.Valid(u.Data) {
+			fmt.Printf("Data text:  %q\n", string(u.Data))
+		}
+	}
+	fmt.Println("=========================================")
+}
+
+// buildtx 在联网机器上构造未签名交易，填充 nonce、gas 价格和链 ID。
+// 参数:
+//
+//	from - 发送方地址。
+//	to - 接收方地址。
+//	amount - 转账金额（wei）。
+//	data - 附带的数据，可以为空。
+//	format - 输出格式，json 或 rlp。
+//	out - 输出文件，为空时输出到标准输出。
+//
+// 返回值:
+//
+//	如果构造过程中发生错误，则返回错误。
+func (c *Client) buildtx(from, to string, amount *big.Int, data []byte, format, out string) error {
+	cli, err := c.dial()
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	ctx := context.Background()
+	fromAddr := common.HexToAddress(from)
+	toAddr := common.HexToAddress(to)
+	c.warnNewRecipient(fromAddr, toAddr)
+	nonce, err := cli.PendingNonceAt(ctx, fromAddr)
+	if err != nil {
+		return fmt.Errorf("failed to get nonce: %w", err)
+	}
+	gasPrice, err := cli.SuggestGasPrice(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get gas price: %w", err)
+	}
+	gas, err := cli.EstimateGas(ctx, ethereum.CallMsg{From: fromAddr, To: &toAddr, Value: amount, Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to estimate gas: %w", c.explainRevert(err))
+	}
+	u := &unsignedTx{
+		From:     fromAddr,
+		ChainID:  (*hexutil.Big)(c.chainID),
+		Nonce:    hexutil.Uint64(nonce),
+		To:       &toAddr,
+		Value:    (*hexutil.Big)(amount),
+		Gas:      hexutil.Uint64(gas),
+		GasPrice: (*hexutil.Big)(gasPrice),
+		Data:     data,
+	}
+
+	switch format {
+	case "json":
+		b, err := json.MarshalIndent(u, "", "  ")
+		if err != nil {
+			return err
+		}
+		return writeOutput(out, string(b))
+	case "rlp":
+		s, err := u.encodeRLP()
+		if err != nil {
+			return err
+		}
+		return writeOutput(out, s)
+	default:
+		return fmt.Errorf("unknown format %q, want json or rlp", format)
+	}
+}
+
+// signtx 在离线机器上对未签名交易进行签名，签名前打印摘要并要求确认。
+// 参数:
+//
+//	from - 签名账户地址，RLP 格式的输入必须提供。
+//	in - 未签名交易文件。
+//	out - 输出已签名交易的文件，为空时输出到标准输出。
+//
+// 返回值:
+//
+//	如果签名过程中发生错误，则返回错误。
+func (c *Client) signtx(from, in, out string) error {
+	u, err := readUnsignedTx(in)
+	if err != nil {
+		return err
+	}
+	if from != "" {
+		fromAddr := common.HexToAddress(from)
+		if u.From != (common.Address{}) && u.From != fromAddr {
+			return fmt.Errorf("transaction is built for %s, not %s", u.From.Hex(), fromAddr.Hex())
+		}
+		u.From = fromAddr
+	}
+	if u.From == (common.Address{}) {
+		return errors.New("signing account unknown, please specify -from")
+	}
+	if c.chainID != nil && u.ChainID.ToInt().Cmp(c.chainID) != 0 {
+		return fmt.Errorf("transaction is built for chain %s, but network %q expects %s", u.ChainID.ToInt(), c.networkName, c.chainID)
+	}
+
+	printUnsignedTx(u)
+	if !confirm("Sign this transaction?") {
+		return errors.New("signing aborted by user")
+	}
+
+	w, err := hdwallet.LoadWallet(u.From.Hex(), c.dataDir)
+	if err != nil {
+		return err
+	}
+	signedTx, err := w.HDKeyStore.SignTx(u.From, u.transaction(), u.ChainID.ToInt())
+	if err != nil {
+		return fmt.Errorf("failed to sign tx: %w", err)
+	}
+	raw, err := signedTx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Tx hash:", signedTx.Hash().Hex())
+	return writeOutput(out, hexutil.Encode(raw))
+}
+
+// broadcast 在联网机器上广播已签名的原始交易。
+// 参数:
+//
+//	in - 已签名交易文件，与 raw 二选一。
+//	raw - 已签名交易的十六进制编码。
+//
+// 返回值:
+//
+//	common.Hash - 交易哈希。
+//	error - 如果广播过程中发生错误，则返回错误。
+func (c *Client) broadcast(in, raw string) (common.Hash, error) {
+	raw, err := readHexInput(in, raw)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	b, err := hexutil.Decode(raw)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("invalid raw transaction: %w", err)
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(b); err != nil {
+		return common.Hash{}, fmt.Errorf("invalid raw transaction: %w", err)
+	}
+
+	cli, err := c.dial()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer cli.Close()
+
+	msg, err := txCallMsg(tx)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("invalid signature: %w", err)
+	}
+	if err := c.simulateTx(context.Background(), cli, msg); err != nil {
+		return common.Hash{}, err
+	}
+	if err := cli.SendTransaction(context.Background(), tx); err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
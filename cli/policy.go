@@ -0,0 +1,99 @@
+package client
+
+import (
+	"fmt"
+	"go_wallet/policy"
+	"go_wallet/units"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// showpolicy 显示账户的花费策略，以及当前链上最近 24 小时内已签名的支出。
+// 参数:
+//
+//	account - 账户地址。
+//
+// 返回值:
+//
+//	如果策略文件无效或连接节点失败，则返回错误。
+func (c *Client) showpolicy(account string) error {
+	addr := common.HexToAddress(account)
+	engine, err := policy.Load(c.dataDir, addr)
+	if err != nil {
+		return err
+	}
+	file := policy.File(c.dataDir, addr)
+	if engine == nil {
+		fmt.Printf("No spending policy for %s, create %s to add one\n", addr.Hex(), file)
+		return nil
+	}
+	if c.chainID == nil {
+		cli, err := c.dial()
+		if err != nil {
+			return err
+		}
+		cli.Close()
+	}
+	p := engine.Policy()
+	fmt.Printf("Spending policy of %s (%s)\n", addr.Hex(), file)
+	if p.MaxGasPrice != "" {
+		fmt.Println("Max gas price:", p.MaxGasPrice)
+	}
+
+	limit := func(s string) string {
+		if s == "" {
+			return "unlimited"
+		}
+		return s
+	}
+	fmt.Printf("%-44s %20s %20s %24s\n", "ASSET", "PER TX", "24H", "SIGNED IN LAST 24H")
+	if p.ETH != nil {
+		spent, err := engine.Usage(c.chainID, common.Address{})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%-44s %20s %20s %24s\n", "ETH", limit(p.ETH.PerTx), limit(p.ETH.Daily), units.FormatEther(spent))
+	}
+	for _, t := range p.Tokens {
+		spent, err := engine.Usage(c.chainID, t.Token)
+		if err != nil {
+			return err
+		}
+		label := t.Token.Hex()
+		if t.Symbol != "" {
+			label = t.Symbol
+		}
+		fmt.Printf("%-44s %20s %20s %24s\n", label, limit(t.PerTx), limit(t.Daily), units.FormatUnits(spent, t.Decimals))
+	}
+
+	addresses := func(list []common.Address) string {
+		hex := make([]string, len(list))
+		for i, a := range list {
+			hex[i] = a.Hex()
+		}
+		return strings.Join(hex, ", ")
+	}
+	if len(p.AllowRecipients) > 0 {
+		fmt.Println("Allowed recipients:", addresses(p.AllowRecipients))
+	}
+	if len(p.DenyRecipients) > 0 {
+		fmt.Println("Denied recipients:", addresses(p.DenyRecipients))
+	}
+	if len(p.AllowMethods) > 0 {
+		fmt.Println("Allowed contract methods:")
+		contracts := make([]string, 0, len(p.AllowMethods))
+		for contract := range p.AllowMethods {
+			contracts = append(contracts, contract)
+		}
+		sort.Strings(contracts)
+		for _, contract := range contracts {
+			fmt.Printf("  %s: %s\n", contract, strings.Join(p.AllowMethods[contract], ", "))
+		}
+		if p.AllowDeploy {
+			fmt.Println("  contract deployment allowed")
+		}
+	}
+	return nil
+}
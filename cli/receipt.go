@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_wallet/units"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const (
+	receiptPollInterval = 2 * time.Second  // 轮询交易回执的间隔
+	receiptWaitTimeout  = 10 * time.Minute // 等待交易确认的最长时间
+)
+
+// waitForReceipt 轮询交易回执，直到交易被打包并达到指定的确认数。
+// 参数:
+//
+//	cli - 以太坊客户端。
+//	hash - 交易哈希。
+//	confirmations - 需要的确认数，小于 1 时按 1 处理。
+//
+// 返回值:
+//
+//	*types.Receipt - 交易回执。
+//	error - 如果超时或查询失败，则返回错误。
+func waitForReceipt(ctx context.Context, cli *ethclient.Client, hash common.Hash, confirmations uint64) (*types.Receipt, error) {
+	if confirmations < 1 {
+		confirmations = 1
+	}
+	ctx, cancel := context.WithTimeout(ctx, receiptWaitTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(receiptPollInterval)
+	defer ticker.Stop()
+
+	for {
+		receipt, err := cli.TransactionReceipt(ctx, hash)
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			return nil, err
+		}
+		if receipt != nil {
+			head, err := cli.BlockNumber(ctx)
+			if err != nil {
+				return nil, err
+			}
+			// 回执所在区块本身算作第一个确认。
+			if head+1 >= receipt.BlockNumber.Uint64()+confirmations {
+				return receipt, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timed out waiting for %d confirmations of %s: %w", confirmations, hash.Hex(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// printReceipt 打印交易回执中的执行结果。
+// 当交易执行失败时，会在回执所在区块之前的状态上重放交易以获取 revert 原因。
+func (c *Client) printReceipt(ctx context.Context, cli *ethclient.Client, tx *types.Transaction, receipt *types.Receipt) {
+	status := "success"
+	if receipt.Status == types.ReceiptStatusFailed {
+		status = "failed"
+	}
+	fmt.Println("Tx hash:", receipt.TxHash.Hex())
+	fmt.Println("Status:", status)
+	fmt.Println("Block number:", receipt.BlockNumber)
+	fmt.Println("Gas used:", receipt.GasUsed)
+	if receipt.EffectiveGasPrice != nil {
+		fmt.Println("Effective gas price:", units.FormatUnits(receipt.EffectiveGasPrice, 9), "gwei")
+		fee := new(big.Int).Mul(receipt.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+		fmt.Println("Fee paid:", units.FormatEther(fee), "ETH")
+	}
+	if receipt.ContractAddress != (common.Address{}) {
+		fmt.Println("Contract address:", receipt.ContractAddress.Hex())
+	}
+	if receipt.Status == types.ReceiptStatusFailed && tx != nil {
+		reason, err := c.revertReason(ctx, cli, tx, receipt.BlockNumber)
+		if err != nil {
+			fmt.Println("Revert reason: unknown,", err)
+		} else {
+			fmt.Println("Revert reason:", reason)
+		}
+	}
+}
+
+// txCallMsg 将已签名交易转换为 eth_call 使用的消息，发送方从签名中恢复。
+func txCallMsg(tx *types.Transaction) (ethereum.CallMsg, error) {
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return ethereum.CallMsg{}, err
+	}
+	msg := ethereum.CallMsg{
+		From:     from,
+		To:       tx.To(),
+		Gas:      tx.Gas(),
+		GasPrice: tx.GasPrice(),
+		Value:    tx.Value(),
+		Data:     tx.Data(),
+	}
+	if tx.Type() != types.LegacyTxType {
+		msg.GasPrice = nil
+		msg.GasFeeCap = tx.GasFeeCap()
+		msg.GasTipCap = tx.GasTipCap()
+	}
+	return msg, nil
+}
+
+// revertReason 在指定区块的父区块状态上重放交易，并解码返回的 revert 原因，
+// 包括 Error(string)、Panic(uint256) 以及签名数据库中已知的自定义错误。
+func (c *Client) revertReason(ctx context.Context, cli *ethclient.Client, tx *types.Transaction, blockNumber *big.Int) (string, error) {
+	msg, err := txCallMsg(tx)
+	if err != nil {
+		return "", err
+	}
+	var at *big.Int
+	if blockNumber != nil && blockNumber.Sign() > 0 {
+		at = new(big.Int).Sub(blockNumber, big.NewInt(1))
+	}
+	_, err = cli.CallContract(ctx, msg, at)
+	if err == nil {
+		return "", errors.New("transaction did not revert when replayed")
+	}
+	if data, ok := revertData(err); ok {
+		return decodeRevert(data, c.revertDB()), nil
+	}
+	return err.Error(), nil
+}
+
+// txstatus 查询指定交易的状态并打印执行结果。
+// 参数:
+//
+//	hash - 交易哈希。
+//
+// 返回值:
+//
+//	如果查询过程中发生错误，则返回错误。
+func (c *Client) txstatus(hash string) error {
+	cli, err := c.dial()
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	ctx := context.Background()
+	txHash := common.HexToHash(hash)
+	tx, pending, err := cli.TransactionByHash(ctx, txHash)
+	if err != nil {
+		return fmt.Errorf("failed to get transaction %s: %w", txHash.Hex(), err)
+	}
+	if pending {
+		fmt.Println("Tx hash:", txHash.Hex())
+		fmt.Println("Status: pending")
+		return nil
+	}
+	receipt, err := cli.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return fmt.Errorf("failed to get receipt of %s: %w", txHash.Hex(), err)
+	}
+	c.printReceipt(ctx, cli, tx, receipt)
+	if head, err := cli.BlockNumber(ctx); err == nil {
+		fmt.Println("Confirmations:", head-receipt.BlockNumber.Uint64()+1)
+	}
+	return nil
+}
+
+// waitTx 等待交易达到指定确认数并打印执行结果。
+func (c *Client) waitTx(hash common.Hash, confirmations uint64) error {
+	cli, err := c.dial()
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	ctx := context.Background()
+	fmt.Printf("Waiting for %d confirmation(s) of %s ...\n", max(confirmations, 1), hash.Hex())
+	receipt, err := waitForReceipt(ctx, cli, hash, confirmations)
+	if err != nil {
+		return err
+	}
+	tx, _, err := cli.TransactionByHash(ctx, hash)
+	if err != nil {
+		tx = nil
+	}
+	c.printReceipt(ctx, cli, tx, receipt)
+	if receipt.Status == types.ReceiptStatusFailed {
+		return fmt.Errorf("transaction %s failed", hash.Hex())
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go_wallet/signatures"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+var (
+	errorSelector = []byte{0x08, 0xc3, 0x79, 0xa0} // Error(string)
+	panicSelector = []byte{0x4e, 0x48, 0x7b, 0x71} // Panic(uint256)
+)
+
+// panicReasons 是 Solidity 0.8 的 Panic 错误码及其含义。
+var panicReasons = map[uint64]string{
+	0x00: "generic compiler panic",
+	0x01: "assertion failed",
+	0x11: "arithmetic overflow or underflow",
+	0x12: "division or modulo by zero",
+	0x21: "invalid enum value",
+	0x22: "invalid storage byte array encoding",
+	0x31: "pop on empty array",
+	0x32: "array index out of bounds",
+	0x41: "out of memory",
+	0x51: "call to uninitialized function",
+}
+
+// revertError 表示交易执行被 revert，reason 是解码后的原因，data 是原始的 revert 数据。
+type revertError struct {
+	reason string
+	data   []byte
+}
+
+func (e *revertError) Error() string {
+	return "execution reverted: " + e.reason
+}
+
+// revertData 从节点返回的错误中提取 revert 数据，错误不是 revert 时返回 false。
+func revertData(err error) ([]byte, bool) {
+	var dataErr interface{ ErrorData() interface{} }
+	if errors.As(err, &dataErr) {
+		if hexData, ok := dataErr.ErrorData().(string); ok {
+			return common.FromHex(hexData), true
+		}
+	}
+	// 没有附带数据的 revert，例如不带消息的 require。
+	if strings.Contains(err.Error(), "execution reverted") {
+		return nil, true
+	}
+	return nil, false
+}
+
+// decodeRevert 将 revert 数据解码为可读的原因，支持 Error(string)、Panic(uint256) 和自定义错误。
+// 自定义错误按选择器在签名数据库中查找，db 可以为 nil。
+func decodeRevert(data []byte, db *signatures.DB) string {
+	switch {
+	case len(data) == 0:
+		return "reverted without a reason (require or revert with no message)"
+	case len(data) < 4:
+		return "invalid revert data " + hexutil.Encode(data)
+	case bytes.Equal(data[:4], errorSelector):
+		if reason, err := abi.UnpackRevert(data); err == nil {
+			return reason
+		}
+	case bytes.Equal(data[:4], panicSelector):
+		if len(data) == 36 {
+			code := new(big.Int).SetBytes(data[4:])
+			reason, ok := panicReasons[code.Uint64()]
+			if !ok || !code.IsUint64() {
+				reason = "unknown panic code"
+			}
+			return fmt.Sprintf("panic 0x%02x: %s", code, reason)
+		}
+	}
+	if db != nil {
+		for _, m := range db.LookupFunction(data[:4]) {
+			values, err := m.Inputs.Unpack(data[4:])
+			if err != nil {
+				continue
+			}
+			args := make([]string, len(values))
+			for i, in := range m.Inputs {
+				args[i] = formatABIValue(in.Type, reflect.ValueOf(values[i]))
+				if in.Name != "" {
+					args[i] = in.Name + ": " + args[i]
+				}
+			}
+			return m.RawName + "(" + strings.Join(args, ", ") + ")"
+		}
+	}
+	return fmt.Sprintf("unknown custom error %s, data %s", hexutil.Encode(data[:4]), hexutil.Encode(data))
+}
+
+// revertDB 加载用于解码自定义错误的签名数据库，并加入调用方给出的 ABI 中声明的错误。
+// 签名数据库只是辅助信息，加载失败时仍返回一个空的数据库。
+func (c *Client) revertDB(abis ...*abi.ABI) *signatures.DB {
+	db, err := c.loadSignatures()
+	if err != nil {
+		db, _ = signatures.Load("")
+	}
+	for _, parsed := range abis {
+		if parsed != nil {
+			db.AddABI(parsed)
+		}
+	}
+	return db
+}
+
+// explainRevert 如果 err 是 revert 错误，则返回带有解码原因的 *revertError，否则原样返回 err。
+func (c *Client) explainRevert(err error, abis ...*abi.ABI) error {
+	if err == nil {
+		return nil
+	}
+	data, ok := revertData(err)
+	if !ok {
+		return err
+	}
+	return &revertError{reason: decodeRevert(data, c.revertDB(abis...)), data: data}
+}
+
+// simulateTx 在发送交易之前，以 eth_call 在最新区块的状态上执行交易。
+// 参数:
+//
+//	cli - 以太坊客户端。
+//	msg - 要执行的交易。
+//	abis - 目标合约的 ABI，用于解码其中声明的自定义错误。
+//
+// 返回值:
+//
+//	如果交易会 revert，则返回带有解码原因的错误；节点返回的其他错误原样返回。
+//	以 -simulate 运行时打印完整的模拟结果，交易可以执行时返回 errSimulated。
+func (c *Client) simulateTx(ctx context.Context, cli *ethclient.Client, msg ethereum.CallMsg, abis ...*abi.ABI) error {
+	if c.simulate {
+		return c.preview(ctx, cli, []ethereum.CallMsg{msg}, abis...)
+	}
+	if _, err := cli.CallContract(ctx, msg, nil); err != nil {
+		return fmt.Errorf("transaction would fail: %w", c.explainRevert(err, abis...))
+	}
+	return nil
+}
+
+// simulateContract 将方法调用按 ABI 编码后，以 from 的身份模拟执行，用法与 simulateTx 相同。
+func (c *Client) simulateContract(ctx context.Context, cli *ethclient.Client, from, contract common.Address, parsed *abi.ABI, value *big.Int, method string, args ...interface{}) error {
+	data, err := parsed.Pack(method, args...)
+	if err != nil {
+		return err
+	}
+	return c.simulateTx(ctx, cli, ethereum.CallMsg{From: from, To: &contract, Value: value, Data: data}, parsed)
+}
@@ -0,0 +1,503 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_wallet/hdwallet"
+	"go_wallet/policy"
+	"go_wallet/units"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// signerAPIVersion 是实现的 clef 外部签名 API 版本，geth 连接时通过 account_version 获取。
+const signerAPIVersion = "6.1.0"
+
+// defaultSignerSocket 是数据目录下默认的 Unix 套接字文件名。
+const defaultSignerSocket = "signer.ipc"
+
+// errRequestDenied 是请求被拒绝时返回给调用方的错误，与 clef 一致。
+var errRequestDenied = errors.New("request denied")
+
+// serveRules 是按规则自动批准请求的规则文件，列出每类请求允许的账户。
+// 规则之外的请求一律拒绝；按规则批准的交易仍然要经过账户的花费策略检查。
+type serveRules struct {
+	ListAccounts    bool             `json:"list_accounts"`
+	SignTransaction []common.Address `json:"sign_transaction"`
+	SignData        []common.Address `json:"sign_data"`
+	SignTypedData   []common.Address `json:"sign_typed_data"`
+}
+
+// loadServeRules 读取规则文件，不允许未知字段，避免拼写错误的规则被静默忽略。
+func loadServeRules(file string) (*serveRules, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var rules serveRules
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&rules); err != nil {
+		return nil, fmt.Errorf("invalid rules file %s: %w", file, err)
+	}
+	return &rules, nil
+}
+
+// accounts 返回规则中出现的所有账户，按首次出现的顺序排列。
+func (r *serveRules) accounts() []common.Address {
+	var list []common.Address
+	seen := make(map[common.Address]bool)
+	for _, group := range [][]common.Address{r.SignTransaction, r.SignData, r.SignTypedData} {
+		for _, a := range group {
+			if !seen[a] {
+				seen[a] = true
+				list = append(list, a)
+			}
+		}
+	}
+	return list
+}
+
+// containsAddress 判断地址列表中是否包含指定地址。
+func containsAddress(list []common.Address, addr common.Address) bool {
+	for _, a := range list {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// signerAPI 实现 clef 的 account 命名空间，所有签名都通过 HDKeyStore 完成，
+// 因此同样受花费策略检查并记录到审计日志。请求逐个处理，每个请求都需要批准。
+type signerAPI struct {
+	c       *Client
+	chainID *big.Int
+	rules   *serveRules                          // 为 nil 时在终端上交互式批准
+	wallets map[common.Address]hdwallet.HDWallet // 规则模式下启动时解锁的账户
+	mu      sync.Mutex
+}
+
+// signTransactionResult 是 account_signTransaction 的返回值，与 geth 的外部签名后端一致。
+type signTransactionResult struct {
+	Raw hexutil.Bytes      `json:"raw"`
+	Tx  *types.Transaction `json:"tx"`
+}
+
+// describePeer 返回请求来源的描述，用于批准提示和日志。
+func describePeer(ctx context.Context) string {
+	peer := rpc.PeerInfoFromContext(ctx)
+	desc := peer.Transport
+	if peer.RemoteAddr != "" {
+		desc += " " + peer.RemoteAddr
+	}
+	if peer.HTTP.UserAgent != "" {
+		desc += " (" + peer.HTTP.UserAgent + ")"
+	}
+	return desc
+}
+
+// approve 请求批准：规则模式下按 allowed 决定，否则打印请求内容并在终端上确认。
+// 参数:
+//
+//	ctx - 请求上下文，用于获取请求来源。
+//	method - 请求的方法名。
+//	allowed - 规则是否允许该请求，交互模式下忽略。
+//	show - 打印请求内容的函数，可以为 nil。
+//
+// 返回值:
+//
+//	请求被拒绝时返回 errRequestDenied。
+func (s *signerAPI) approve(ctx context.Context, method string, allowed bool, show func()) error {
+	fmt.Printf("========== %s %s from %s ==========\n", time.Now().Format(time.RFC3339), method, describePeer(ctx))
+	if show != nil {
+		show()
+	}
+	if s.rules != nil {
+		if !allowed {
+			fmt.Println("Rejected: not allowed by the rules")
+			return errRequestDenied
+		}
+		fmt.Println("Approved by the rules")
+		return nil
+	}
+	if !confirm("Approve this request?") {
+		fmt.Println("Rejected by the operator")
+		return errRequestDenied
+	}
+	return nil
+}
+
+// wallet 返回签名账户的钱包：规则模式下使用启动时解锁的密钥，交互模式下每次提示输入密码解锁。
+func (s *signerAPI) wallet(account common.Address) (hdwallet.HDWallet, error) {
+	if s.rules != nil {
+		w, ok := s.wallets[account]
+		if !ok {
+			return hdwallet.HDWallet{}, fmt.Errorf("account %s is not unlocked", account.Hex())
+		}
+		return w, nil
+	}
+	return hdwallet.LoadWallet(account.Hex(), s.c.dataDir)
+}
+
+// Version 实现 account_version。
+func (s *signerAPI) Version(ctx context.Context) (string, error) {
+	return signerAPIVersion, nil
+}
+
+// List 实现 account_list，返回可以签名的账户。
+func (s *signerAPI) List(ctx context.Context) ([]common.Address, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []common.Address
+	if s.rules != nil {
+		list = s.rules.accounts()
+	} else {
+		all, err := hdwallet.ListAccounts(s.c.dataDir)
+		if err != nil {
+			return nil, err
+		}
+		list = all
+	}
+	err := s.approve(ctx, "account_list", s.rules != nil && s.rules.ListAccounts, func() {
+		for _, a := range list {
+			fmt.Println("Account:   ", a.Hex())
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		list = []common.Address{}
+	}
+	return list, nil
+}
+
+// SignTransaction 实现 account_signTransaction，返回签名后的交易及其 RLP 编码，不广播交易。
+// 请求中的链 ID 必须与钱包配置的链 ID 一致。
+func (s *signerAPI) SignTransaction(ctx context.Context, args apitypes.SendTxArgs, methodSelector *string) (*signTransactionResult, error) {
+	if args.ChainID != nil && args.ChainID.ToInt().Cmp(s.chainID) != 0 {
+		return nil, fmt.Errorf("chain id mismatch: request %s, configured %s", args.ChainID.ToInt(), s.chainID)
+	}
+	if args.Data != nil && args.Input != nil && !strings.EqualFold(args.Data.String(), args.Input.String()) {
+		return nil, errors.New("both data and input are set and differ")
+	}
+	switch {
+	case args.MaxFeePerGas != nil:
+		if args.MaxPriorityFeePerGas == nil {
+			return nil, errors.New("maxPriorityFeePerGas is required with maxFeePerGas")
+		}
+	case args.GasPrice == nil:
+		return nil, errors.New("gasPrice or maxFeePerGas is required")
+	}
+	args.ChainID = (*hexutil.Big)(s.chainID)
+	from := args.From.Address()
+	tx := args.ToTransaction()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	allowed := s.rules != nil && containsAddress(s.rules.SignTransaction, from)
+	if err := s.approve(ctx, "account_signTransaction", allowed, func() { s.printTxRequest(from, tx) }); err != nil {
+		return nil, err
+	}
+	w, err := s.wallet(from)
+	if err != nil {
+		return nil, err
+	}
+	signedTx, err := w.HDKeyStore.SignTx(from, tx, s.chainID)
+	if err != nil {
+		fmt.Println("Failed to sign transaction:", err)
+		return nil, err
+	}
+	raw, err := signedTx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("Signed transaction", signedTx.Hash().Hex())
+	return &signTransactionResult{Raw: raw, Tx: signedTx}, nil
+}
+
+// printTxRequest 打印待签名交易的可读摘要，调用数据按签名数据库解析。
+func (s *signerAPI) printTxRequest(from common.Address, tx *types.Transaction) {
+	fmt.Println("From:       ", from.Hex())
+	if tx.To() != nil {
+		fmt.Println("To:         ", tx.To().Hex())
+		s.c.warnNewRecipient(from, *tx.To())
+	} else {
+		fmt.Println("To:          (contract creation)")
+	}
+	fmt.Println("Value:      ", units.FormatEther(tx.Value()), "ETH")
+	fmt.Println("Chain ID:   ", s.chainID)
+	fmt.Println("Nonce:      ", tx.Nonce())
+	fmt.Println("Gas limit:  ", tx.Gas())
+	if tx.Type() == types.DynamicFeeTxType {
+		fmt.Println("Max fee:    ", units.FormatUnits(tx.GasFeeCap(), 9), "gwei")
+		fmt.Println("Priority:   ", units.FormatUnits(tx.GasTipCap(), 9), "gwei")
+	} else {
+		fmt.Println("Gas price:  ", units.FormatUnits(tx.GasPrice(), 9), "gwei")
+	}
+	fee := new(big.Int).Mul(tx.GasFeeCap(), new(big.Int).SetUint64(tx.Gas()))
+	fmt.Println("Max tx fee: ", units.FormatEther(fee), "ETH")
+
+	data := tx.Data()
+	if len(data) == 0 {
+		return
+	}
+	fmt.Println("Data:       ", hexutil.Encode(data))
+	if len(data) >= 4 && tx.To() != nil {
+		if err := s.c.abidecode("", "", hexutil.Encode(data)); err != nil {
+			fmt.Println("Calldata:   ", err)
+		}
+	} else if utf8.Valid(data) {
+		fmt.Printf("Data text:   %q\n", string(data))
+	}
+}
+
+// SignData 实现 account_signData。支持 text/plain（EIP-191 personal_sign，data 为十六进制编码的消息）
+// 和 data/typed（EIP-712，data 为结构化数据），签名的 v 取值为 27 或 28。
+func (s *signerAPI) SignData(ctx context.Context, contentType string, addr common.MixedcaseAddress, data interface{}) (hexutil.Bytes, error) {
+	switch contentType {
+	case accounts.MimetypeTextPlain:
+		text, ok := data.(string)
+		if !ok {
+			return nil, errors.New("data must be a hex string for text/plain")
+		}
+		msg, err := hexutil.Decode(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid text/plain data: %w", err)
+		}
+		return s.signMessage(ctx, addr.Address(), msg)
+	case accounts.MimetypeTypedData:
+		content, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		var typedData apitypes.TypedData
+		if err := json.Unmarshal(content, &typedData); err != nil {
+			return nil, fmt.Errorf("invalid typed data: %w", err)
+		}
+		return s.signTypedData(ctx, "account_signData", addr.Address(), typedData)
+	default:
+		return nil, fmt.Errorf("unsupported content type %q, only %s and %s are supported", contentType, accounts.MimetypeTextPlain, accounts.MimetypeTypedData)
+	}
+}
+
+// signMessage 在批准后按 EIP-191 规则对消息签名。
+func (s *signerAPI) signMessage(ctx context.Context, account common.Address, msg []byte) (hexutil.Bytes, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	allowed := s.rules != nil && containsAddress(s.rules.SignData, account)
+	err := s.approve(ctx, "account_signData", allowed, func() {
+		fmt.Println("Account:   ", account.Hex())
+		if utf8.Valid(msg) {
+			fmt.Printf("Message (%d bytes): %q\n", len(msg), string(msg))
+		} else {
+			fmt.Printf("Message (%d bytes): %s\n", len(msg), hexutil.Encode(msg))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	w, err := s.wallet(account)
+	if err != nil {
+		return nil, err
+	}
+	return w.SignMessage(msg)
+}
+
+// SignTypedData 实现 account_signTypedData，按 EIP-712 规则签名，v 取值为 27 或 28。
+func (s *signerAPI) SignTypedData(ctx context.Context, addr common.MixedcaseAddress, typedData apitypes.TypedData) (hexutil.Bytes, error) {
+	return s.signTypedData(ctx, "account_signTypedData", addr.Address(), typedData)
+}
+
+// signTypedData 在批准后对结构化数据签名，结构化数据的链 ID 必须与钱包配置的链 ID 一致。
+func (s *signerAPI) signTypedData(ctx context.Context, method string, account common.Address, typedData apitypes.TypedData) (hexutil.Bytes, error) {
+	if id := typedData.Domain.ChainId; id != nil && (*big.Int)(id).Cmp(s.chainID) != 0 {
+		return nil, fmt.Errorf("chain id mismatch: typed data %s, configured %s", (*big.Int)(id), s.chainID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	allowed := s.rules != nil && containsAddress(s.rules.SignTypedData, account)
+	var printErr error
+	err := s.approve(ctx, method, allowed, func() {
+		fmt.Println("Account:   ", account.Hex())
+		printErr = printTypedData(typedData)
+	})
+	if printErr != nil {
+		return nil, printErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	w, err := s.wallet(account)
+	if err != nil {
+		return nil, err
+	}
+	return w.SignTypedData(typedData)
+}
+
+// localOnly 只接受发往回环地址的 HTTP 请求，并拒绝浏览器发起的跨域请求，
+// 防止网页通过 DNS 重绑定或跨站请求访问签名服务。
+func localOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		ip := net.ParseIP(host)
+		if host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			http.Error(w, "invalid host specified", http.StatusForbidden)
+			return
+		}
+		if r.Header.Get("Origin") != "" {
+			http.Error(w, "cross-origin requests are not allowed", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// listenSocket 在 Unix 套接字上监听，删除之前异常退出遗留的套接字文件，并只允许当前用户访问。
+func listenSocket(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is in use by another signer", path)
+		}
+		os.Remove(path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// serve 以常驻进程运行 clef 兼容的外部签名服务，geth 可以通过 --signer 使用钱包中的账户签名，而不需要持有密钥。
+// 没有给出规则文件时，每个请求都在终端上打印内容并要求确认，签名前提示输入账户密码；
+// 给出规则文件时，启动时解锁规则中的账户，按规则批准请求，其余请求一律拒绝。
+// 参数:
+//
+//	socket - Unix 套接字路径，socket 和 httpAddr 都为空时使用数据目录下的 signer.ipc。
+//	httpAddr - HTTP 监听地址，只能是回环地址，例如 127.0.0.1:8550，可以为空。
+//	rulesFile - 规则文件，为空时交互式批准。
+//
+// 返回值:
+//
+//	如果无法监听、规则无效或解锁账户失败，则返回错误。
+func (c *Client) serve(socket, httpAddr, rulesFile string) error {
+	if c.chainID == nil {
+		cli, err := c.dial()
+		if err != nil {
+			return fmt.Errorf("chain id is not configured and the node is unreachable: %w", err)
+		}
+		cli.Close()
+	}
+	api := &signerAPI{c: c, chainID: c.chainID}
+
+	if rulesFile != "" {
+		rules, err := loadServeRules(rulesFile)
+		if err != nil {
+			return err
+		}
+		api.rules = rules
+		api.wallets = make(map[common.Address]hdwallet.HDWallet)
+		for _, account := range rules.accounts() {
+			w, err := hdwallet.LoadWallet(account.Hex(), c.dataDir)
+			if err != nil {
+				return fmt.Errorf("failed to unlock %s: %w", account.Hex(), err)
+			}
+			api.wallets[account] = w
+			if containsAddress(rules.SignTransaction, account) && w.HDKeyStore.Guard == nil {
+				fmt.Printf("Warning: transactions of %s are approved by rules without a spending policy, create %s to limit them\n", account.Hex(), policy.File(c.dataDir, account))
+			}
+		}
+	}
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("account", api); err != nil {
+		return err
+	}
+
+	if socket == "" && httpAddr == "" {
+		socket = filepath.Join(c.dataDir, defaultSignerSocket)
+	}
+	errc := make(chan error, 2)
+	if socket != "" {
+		l, err := listenSocket(socket)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(socket)
+		defer l.Close()
+		go func() { errc <- server.ServeListener(l) }()
+		fmt.Println("Signer listening on", socket)
+	}
+	if httpAddr != "" {
+		host, _, err := net.SplitHostPort(httpAddr)
+		if err != nil {
+			return fmt.Errorf("invalid http address %q: %w", httpAddr, err)
+		}
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return fmt.Errorf("refusing to listen on %s, only loopback addresses are allowed", httpAddr)
+		}
+		l, err := net.Listen("tcp", httpAddr)
+		if err != nil {
+			return err
+		}
+		srv := &http.Server{Handler: localOnly(server), ReadHeaderTimeout: 10 * time.Second}
+		defer srv.Close()
+		go func() { errc <- srv.Serve(l) }()
+		fmt.Println("Signer listening on http://" + l.Addr().String())
+	}
+	if api.rules != nil {
+		fmt.Println("Requests are approved by the rules in", rulesFile)
+	} else {
+		fmt.Println("Every request must be approved on this terminal")
+	}
+	fmt.Println("Chain ID:", c.chainID)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	select {
+	case <-ctx.Done():
+		fmt.Println("Signer stopped")
+		return nil
+	case err := <-errc:
+		return err
+	}
+}
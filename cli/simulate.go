@@ -0,0 +1,327 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go_wallet/sol"
+	"go_wallet/units"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// errSimulated 表示命令以 -simulate 运行，交易只做了模拟，没有签名和发送。
+var errSimulated = errors.New("simulation only, transaction not sent")
+
+// callFrame 是 debug_traceCall 使用 callTracer 时返回的调用帧。
+type callFrame struct {
+	Type    string          `json:"type"`
+	From    common.Address  `json:"from"`
+	To      *common.Address `json:"to"`
+	Value   *hexutil.Big    `json:"value"`
+	GasUsed hexutil.Uint64  `json:"gasUsed"`
+	Error   string          `json:"error"`
+	Calls   []callFrame     `json:"calls"`
+	Logs    []callLog       `json:"logs"`
+}
+
+// callLog 是调用帧中产生的日志。
+type callLog struct {
+	Address common.Address `json:"address"`
+	Topics  []common.Hash  `json:"topics"`
+	Data    hexutil.Bytes  `json:"data"`
+}
+
+// balanceChanges 记录模拟执行得到的余额变化：资产 -> 账户 -> 变化量，资产为空地址时表示 ETH。
+type balanceChanges map[common.Address]map[common.Address]*big.Int
+
+func (b balanceChanges) add(asset, account common.Address, delta *big.Int) {
+	if b[asset] == nil {
+		b[asset] = make(map[common.Address]*big.Int)
+	}
+	if b[asset][account] == nil {
+		b[asset][account] = new(big.Int)
+	}
+	b[asset][account].Add(b[asset][account], delta)
+}
+
+// transfer 记录一笔从 from 到 to 的资产转移。
+func (b balanceChanges) transfer(asset, from, to common.Address, value *big.Int) {
+	if value == nil || value.Sign() == 0 {
+		return
+	}
+	b.add(asset, from, new(big.Int).Neg(value))
+	b.add(asset, to, value)
+}
+
+// toCallArg 将交易消息转换为 debug_traceCall 的参数，与 eth_call 的参数格式相同。
+func toCallArg(msg ethereum.CallMsg) map[string]interface{} {
+	arg := map[string]interface{}{"from": msg.From}
+	if msg.To != nil {
+		arg["to"] = msg.To
+	}
+	if len(msg.Data) > 0 {
+		arg["input"] = hexutil.Bytes(msg.Data)
+	}
+	if msg.Value != nil {
+		arg["value"] = (*hexutil.Big)(msg.Value)
+	}
+	if msg.Gas != 0 {
+		arg["gas"] = hexutil.Uint64(msg.Gas)
+	}
+	if msg.GasPrice != nil {
+		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
+	}
+	if msg.GasFeeCap != nil {
+		arg["maxFeePerGas"] = (*hexutil.Big)(msg.GasFeeCap)
+	}
+	if msg.GasTipCap != nil {
+		arg["maxPriorityFeePerGas"] = (*hexutil.Big)(msg.GasTipCap)
+	}
+	return arg
+}
+
+// traceCall 通过 debug_traceCall 的 callTracer 执行交易，返回包含内部调用和日志的调用树。
+func traceCall(ctx context.Context, cli *ethclient.Client, msg ethereum.CallMsg) (*callFrame, error) {
+	var frame callFrame
+	config := map[string]interface{}{
+		"tracer":       "callTracer",
+		"tracerConfig": map[string]interface{}{"withLog": true},
+	}
+	if err := cli.Client().CallContext(ctx, &frame, "debug_traceCall", toCallArg(msg), "latest", config); err != nil {
+		return nil, err
+	}
+	return &frame, nil
+}
+
+// collectChanges 遍历调用树，记录内部调用中的 ETH 转账和 ERC20 Transfer 事件；失败的调用帧及其子调用被忽略。
+func collectChanges(frame *callFrame, transferID common.Hash, changes balanceChanges) {
+	if frame.Error != "" {
+		return
+	}
+	if frame.To != nil && frame.Value != nil && frame.Type != "DELEGATECALL" && frame.Type != "STATICCALL" {
+		changes.transfer(common.Address{}, frame.From, *frame.To, frame.Value.ToInt())
+	}
+	for _, l := range frame.Logs {
+		// ERC721 的 Transfer 有 4 个主题，不是金额转移。
+		if len(l.Topics) == 3 && l.Topics[0] == transferID && len(l.Data) == 32 {
+			from := common.BytesToAddress(l.Topics[1].Bytes())
+			to := common.BytesToAddress(l.Topics[2].Bytes())
+			changes.transfer(l.Address, from, to, new(big.Int).SetBytes(l.Data))
+		}
+	}
+	for i := range frame.Calls {
+		collectChanges(&frame.Calls[i], transferID, changes)
+	}
+}
+
+// calldataChanges 在节点不支持 debug_traceCall 时，根据调用数据推断余额变化：
+// 交易附带的 ETH，以及对代币合约的 transfer 和 transferFrom 调用。
+func calldataChanges(msg ethereum.CallMsg, erc20 *abi.ABI, changes balanceChanges) {
+	if msg.To == nil {
+		return
+	}
+	changes.transfer(common.Address{}, msg.From, *msg.To, msg.Value)
+	if len(msg.Data) < 4 {
+		return
+	}
+	method, err := erc20.MethodById(msg.Data[:4])
+	if err != nil {
+		return
+	}
+	args, err := method.Inputs.Unpack(msg.Data[4:])
+	if err != nil {
+		return
+	}
+	switch method.Name {
+	case "transfer":
+		changes.transfer(*msg.To, msg.From, args[0].(common.Address), args[1].(*big.Int))
+	case "transferFrom":
+		changes.transfer(*msg.To, args[0].(common.Address), args[1].(common.Address), args[2].(*big.Int))
+	}
+}
+
+// preview 模拟执行一组交易并打印结果，用于 -simulate 模式。
+// 每笔交易先以 eth_call 执行以检查是否 revert，节点支持时再以 debug_traceCall 跟踪内部调用，
+// 得到实际消耗的 gas 和所有 ETH、ERC20 余额变化；否则使用估算的 gas 和根据调用数据推断的余额变化。
+// 多笔交易都在最新区块的状态上分别执行，互不影响。
+// 参数:
+//
+//	cli - 以太坊客户端。
+//	msgs - 要模拟的交易。
+//	abis - 目标合约的 ABI，用于解码其中声明的自定义错误。
+//
+// 返回值:
+//
+//	所有交易都执行成功时返回 errSimulated，否则返回第一笔失败交易的原因。
+func (c *Client) preview(ctx context.Context, cli *ethclient.Client, msgs []ethereum.CallMsg, abis ...*abi.ABI) error {
+	erc20, err := sol.TokenMetaData.GetAbi()
+	if err != nil {
+		return err
+	}
+	transferID := erc20.Events["Transfer"].ID
+	gasPrice, err := cli.SuggestGasPrice(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get gas price: %w", err)
+	}
+
+	var (
+		changes  = make(balanceChanges)
+		fee      = new(big.Int)
+		gasTotal uint64
+		traced   = true
+		firstErr error
+		accounts []common.Address // 按出现顺序排列的发送方和接收方
+	)
+	seen := make(map[common.Address]bool)
+	addAccount := func(addr common.Address) {
+		if !seen[addr] {
+			seen[addr] = true
+			accounts = append(accounts, addr)
+		}
+	}
+	fmt.Println("========== Simulation ==========")
+	for i, msg := range msgs {
+		addAccount(msg.From)
+		to := "contract creation"
+		if msg.To != nil {
+			addAccount(*msg.To)
+			to = msg.To.Hex()
+		}
+		prefix := ""
+		if len(msgs) > 1 {
+			prefix = fmt.Sprintf("[%d] ", i+1)
+		}
+		if _, err := cli.CallContract(ctx, msg, nil); err != nil {
+			err = c.explainRevert(err, abis...)
+			fmt.Printf("%s%s -> %s: %v\n", prefix, msg.From.Hex(), to, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("transaction would fail: %w", err)
+			}
+			continue
+		}
+
+		var gas uint64
+		frame, err := traceCall(ctx, cli, msg)
+		if err == nil {
+			gas = uint64(frame.GasUsed)
+			collectChanges(frame, transferID, changes)
+		} else {
+			traced = false
+			if gas, err = cli.EstimateGas(ctx, msg); err != nil {
+				return fmt.Errorf("failed to estimate gas: %w", c.explainRevert(err, abis...))
+			}
+			calldataChanges(msg, erc20, changes)
+		}
+		price := gasPrice
+		switch {
+		case msg.GasPrice != nil:
+			price = msg.GasPrice
+		case msg.GasFeeCap != nil:
+			price = msg.GasFeeCap
+		}
+		cost := new(big.Int).Mul(price, new(big.Int).SetUint64(gas))
+		changes.add(common.Address{}, msg.From, new(big.Int).Neg(cost))
+		fee.Add(fee, cost)
+		gasTotal += gas
+		fmt.Printf("%s%s -> %s: success, gas used %d\n", prefix, msg.From.Hex(), to, gas)
+	}
+	if firstErr != nil && len(msgs) == 1 {
+		return firstErr
+	}
+	fmt.Printf("Total gas used: %d\n", gasTotal)
+	fmt.Printf("Fee: %s ETH\n", units.FormatEther(fee))
+	if traced {
+		fmt.Println("Balance changes (traced with debug_traceCall):")
+	} else {
+		fmt.Println("Balance changes (debug_traceCall not supported, inferred from calldata, internal transfers not included):")
+	}
+	if err := c.printChanges(ctx, cli, changes, accounts); err != nil {
+		return err
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+	return errSimulated
+}
+
+// printChanges 打印余额变化表，发送方和接收方在前，其他账户按地址排序。
+func (c *Client) printChanges(ctx context.Context, cli *ethclient.Client, changes balanceChanges, accounts []common.Address) error {
+	seen := make(map[common.Address]bool)
+	for _, addr := range accounts {
+		seen[addr] = true
+	}
+	var others []common.Address
+	for _, byAccount := range changes {
+		for addr := range byAccount {
+			if !seen[addr] {
+				seen[addr] = true
+				others = append(others, addr)
+			}
+		}
+	}
+	sort.Slice(others, func(i, j int) bool { return bytes.Compare(others[i][:], others[j][:]) < 0 })
+	accounts = append(accounts, others...)
+
+	// ETH 在前，代币按合约地址排序。
+	assets := make([]common.Address, 0, len(changes))
+	for asset := range changes {
+		assets = append(assets, asset)
+	}
+	sort.Slice(assets, func(i, j int) bool { return bytes.Compare(assets[i][:], assets[j][:]) < 0 })
+
+	fmt.Printf("%-42s %-10s %24s %24s %24s\n", "ADDRESS", "ASSET", "BEFORE", "AFTER", "CHANGE")
+	printed := 0
+	for _, asset := range assets {
+		symbol, decimals := "ETH", units.EtherDecimals
+		var token *sol.Token
+		if asset != (common.Address{}) {
+			symbol, decimals = asset.Hex()[:10], 0
+			if info, err := c.resolveToken(cli, asset.Hex()); err == nil {
+				symbol, decimals = info.Symbol, int(info.Decimals)
+			}
+			var err error
+			if token, err = sol.NewToken(asset, cli); err != nil {
+				return err
+			}
+		}
+		for _, addr := range accounts {
+			delta := changes[asset][addr]
+			// 空地址是铸造和销毁代币时 Transfer 事件的来源和去向，不是真实账户。
+			if delta == nil || delta.Sign() == 0 || addr == (common.Address{}) {
+				continue
+			}
+			var (
+				before *big.Int
+				err    error
+			)
+			if token == nil {
+				before, err = cli.BalanceAt(ctx, addr, nil)
+			} else {
+				before, err = token.BalanceOf(&bind.CallOpts{Context: ctx}, addr)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get %s balance of %s: %w", symbol, addr.Hex(), err)
+			}
+			after := new(big.Int).Add(before, delta)
+			change := units.FormatUnits(delta, decimals)
+			if delta.Sign() > 0 {
+				change = "+" + change
+			}
+			fmt.Printf("%-42s %-10s %24s %24s %24s\n", addr.Hex(), symbol, units.FormatUnits(before, decimals), units.FormatUnits(after, decimals), change)
+			printed++
+		}
+	}
+	if printed == 0 {
+		fmt.Println("(no balance changes)")
+	}
+	return nil
+}
@@ -0,0 +1,307 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"go_wallet/sol"
+	"io"
+	"math/big"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// newTestChain 创建一个模拟链，返回预先充值 100 ETH 的账户，以及连接到模拟链的 ethclient.Client。
+// 模拟链没有注册 debug 命名空间，debug_traceCall 不可用。
+func newTestChain(t *testing.T) (*simulated.Backend, *ethclient.Client, *bind.TransactOpts) {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := bind.NewKeyedTransactorWithChainID(key, params.AllDevChainProtocolChanges.ChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance := new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))
+	sim := simulated.NewBackend(types.GenesisAlloc{auth.From: {Balance: balance}})
+	t.Cleanup(func() { sim.Close() })
+	// 模拟链的客户端内嵌了命令行使用的 *ethclient.Client，但没有导出类型，只能通过反射取出。
+	cli := reflect.ValueOf(sim.Client()).FieldByName("Client").Interface().(*ethclient.Client)
+	return sim, cli, auth
+}
+
+// newTestClient 返回使用临时数据目录和模拟链 ID 的命令行客户端。
+func newTestClient(t *testing.T) *Client {
+	return &Client{dataDir: t.TempDir(), chainID: params.AllDevChainProtocolChanges.ChainID}
+}
+
+// mined 出块并检查交易执行成功，返回交易回执。
+func mined(t *testing.T, sim *simulated.Backend, tx *types.Transaction) *types.Receipt {
+	t.Helper()
+	sim.Commit()
+	receipt, err := sim.Client().TransactionReceipt(context.Background(), tx.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("transaction %s failed", tx.Hash().Hex())
+	}
+	return receipt
+}
+
+// deployTestToken 部署 sol/token.sol 并给部署者铸造 supply 个代币。
+func deployTestToken(t *testing.T, sim *simulated.Backend, owner *bind.TransactOpts, supply int64) (common.Address, *sol.Token) {
+	t.Helper()
+	address, tx, token, err := sol.DeployToken(owner, sim.Client(), "GWT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mined(t, sim, tx)
+	tx, err = token.Mint(owner, owner.From, big.NewInt(supply))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mined(t, sim, tx)
+	return address, token
+}
+
+// captureStdout 执行 fn 并返回其写到标准输出的内容。
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	done := make(chan string)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- string(out)
+	}()
+	defer func() { os.Stdout = stdout }()
+	fn()
+	w.Close()
+	return <-done
+}
+
+// changeRow 返回余额变化表中 addr 在 asset 下的一行，按空白分割为字段。
+func changeRow(t *testing.T, out string, addr common.Address, asset string) []string {
+	t.Helper()
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 5 && fields[0] == addr.Hex() && fields[1] == asset {
+			return fields
+		}
+	}
+	t.Fatalf("no %s row for %s in output:\n%s", asset, addr.Hex(), out)
+	return nil
+}
+
+func TestPreviewBalanceChanges(t *testing.T) {
+	sim, cli, owner := newTestChain(t)
+	tokenAddr, token := deployTestToken(t, sim, owner, 1000)
+	erc20, err := sol.TokenMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.HexToAddress("0x703c4b2bD70c169f5717101CaeE543299Fc946C7")
+	data, err := erc20.Pack("transfer", to, big.NewInt(250))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs := []ethereum.CallMsg{
+		{From: owner.From, To: &to, Value: big.NewInt(params.Ether)},
+		{From: owner.From, To: &tokenAddr, Data: data},
+	}
+
+	c := newTestClient(t)
+	var previewErr error
+	out := captureStdout(t, func() { previewErr = c.preview(context.Background(), cli, msgs, erc20) })
+	if !errors.Is(previewErr, errSimulated) {
+		t.Fatalf("preview returned %v, want errSimulated\n%s", previewErr, out)
+	}
+	if !strings.Contains(out, "debug_traceCall not supported") {
+		t.Fatalf("expected the calldata fallback, got:\n%s", out)
+	}
+
+	// ETH 转账消耗 21000 gas，代币转账的 gas 为估算值，不应低于实际执行消耗的 gas。
+	matches := regexp.MustCompile(`success, gas used (\d+)`).FindAllStringSubmatch(out, -1)
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 successful transactions, got:\n%s", out)
+	}
+	if matches[0][1] != "21000" {
+		t.Fatalf("ETH transfer gas = %s, want 21000", matches[0][1])
+	}
+	estimated, _ := strconv.ParseUint(matches[1][1], 10, 64)
+
+	// 以太币：接收方 +1，发送方减少 1 ETH 加手续费；代币：发送方 -250，接收方 +250。
+	if row := changeRow(t, out, to, "ETH"); row[2] != "0" || row[3] != "1" || row[4] != "+1" {
+		t.Fatalf("unexpected ETH row for recipient: %v", row)
+	}
+	if row := changeRow(t, out, owner.From, "ETH"); !strings.HasPrefix(row[4], "-1.") {
+		t.Fatalf("unexpected ETH row for sender: %v", row)
+	}
+	if row := changeRow(t, out, owner.From, "GWT"); row[2] != "1000" || row[3] != "750" || row[4] != "-250" {
+		t.Fatalf("unexpected token row for sender: %v", row)
+	}
+	if row := changeRow(t, out, to, "GWT"); row[2] != "0" || row[3] != "250" || row[4] != "+250" {
+		t.Fatalf("unexpected token row for recipient: %v", row)
+	}
+
+	// 模拟不会改变链上状态。
+	sim.Commit()
+	balance, err := token.BalanceOf(nil, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Sign() != 0 {
+		t.Fatalf("recipient token balance after preview = %s, want 0", balance)
+	}
+
+	tx, err := token.Transfer(owner, to, big.NewInt(250))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt := mined(t, sim, tx); estimated < receipt.GasUsed {
+		t.Fatalf("previewed gas %d is lower than the gas used %d", estimated, receipt.GasUsed)
+	}
+}
+
+func TestPreviewRevert(t *testing.T) {
+	sim, cli, owner := newTestChain(t)
+	tokenAddr, _ := deployTestToken(t, sim, owner, 10)
+	erc20, err := sol.TokenMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.HexToAddress("0x703c4b2bD70c169f5717101CaeE543299Fc946C7")
+
+	c := newTestClient(t)
+	c.simulate = true
+	var simErr error
+	out := captureStdout(t, func() {
+		simErr = c.simulateContract(context.Background(), cli, owner.From, tokenAddr, erc20, nil, "transfer", to, big.NewInt(11))
+	})
+	var revert *revertError
+	if !errors.As(simErr, &revert) {
+		t.Fatalf("simulateContract returned %v, want a revert error\n%s", simErr, out)
+	}
+	if !strings.Contains(simErr.Error(), "transaction would fail") || !strings.Contains(out, "execution reverted") {
+		t.Fatalf("unexpected revert report %q:\n%s", simErr, out)
+	}
+	if strings.Contains(out, "Balance changes") {
+		t.Fatalf("a single failing transaction should not report balance changes:\n%s", out)
+	}
+
+	// 不以 -simulate 运行时，只检查交易能否执行。
+	c.simulate = false
+	if err := c.simulateContract(context.Background(), cli, owner.From, tokenAddr, erc20, nil, "transfer", to, big.NewInt(11)); !errors.As(err, &revert) {
+		t.Fatalf("simulateContract returned %v, want a revert error", err)
+	}
+	if err := c.simulateContract(context.Background(), cli, owner.From, tokenAddr, erc20, nil, "transfer", to, big.NewInt(10)); err != nil {
+		t.Fatalf("simulateContract returned %v for a valid transfer", err)
+	}
+}
+
+func TestTraceCallUnavailable(t *testing.T) {
+	_, cli, owner := newTestChain(t)
+	to := common.HexToAddress("0x703c4b2bD70c169f5717101CaeE543299Fc946C7")
+	if _, err := traceCall(context.Background(), cli, ethereum.CallMsg{From: owner.From, To: &to}); err == nil {
+		t.Fatal("debug_traceCall succeeded on a node without the debug API")
+	}
+}
+
+func TestCollectChanges(t *testing.T) {
+	erc20, err := sol.TokenMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	transferID := erc20.Events["Transfer"].ID
+	var (
+		user   = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		router = common.HexToAddress("0x2222222222222222222222222222222222222222")
+		token  = common.HexToAddress("0x3333333333333333333333333333333333333333")
+		pool   = common.HexToAddress("0x4444444444444444444444444444444444444444")
+	)
+	amount := hexutil.Bytes(common.BigToHash(big.NewInt(500)).Bytes())
+	frame := &callFrame{
+		Type:  "CALL",
+		From:  user,
+		To:    &router,
+		Value: (*hexutil.Big)(big.NewInt(3)),
+		Calls: []callFrame{
+			{
+				// 内部调用把收到的 ETH 转给 pool，并从 pool 转出代币给 user。
+				Type:  "CALL",
+				From:  router,
+				To:    &pool,
+				Value: (*hexutil.Big)(big.NewInt(2)),
+				Logs: []callLog{{
+					Address: token,
+					Topics:  []common.Hash{transferID, common.BytesToHash(pool.Bytes()), common.BytesToHash(user.Bytes())},
+					Data:    amount,
+				}},
+			},
+			{
+				// 委托调用不转移 ETH。
+				Type:  "DELEGATECALL",
+				From:  router,
+				To:    &pool,
+				Value: (*hexutil.Big)(big.NewInt(7)),
+			},
+			{
+				// 失败的调用及其日志被忽略。
+				Type:  "CALL",
+				From:  router,
+				To:    &pool,
+				Value: (*hexutil.Big)(big.NewInt(1)),
+				Error: "execution reverted",
+				Logs: []callLog{{
+					Address: token,
+					Topics:  []common.Hash{transferID, common.BytesToHash(pool.Bytes()), common.BytesToHash(user.Bytes())},
+					Data:    amount,
+				}},
+			},
+		},
+		Logs: []callLog{{
+			// ERC721 的 Transfer 有 4 个主题，不是金额转移。
+			Address: token,
+			Topics:  []common.Hash{transferID, common.BytesToHash(user.Bytes()), common.BytesToHash(pool.Bytes()), common.BigToHash(big.NewInt(1))},
+		}},
+	}
+	changes := make(balanceChanges)
+	collectChanges(frame, transferID, changes)
+
+	want := map[common.Address]map[common.Address]int64{
+		{}:    {user: -3, router: 1, pool: 2},
+		token: {pool: -500, user: 500},
+	}
+	for asset, byAccount := range want {
+		for addr, delta := range byAccount {
+			if got := changes[asset][addr]; got == nil || got.Int64() != delta {
+				t.Errorf("change of %s in %s = %v, want %d", addr.Hex(), asset.Hex(), got, delta)
+			}
+		}
+		if len(changes[asset]) != len(byAccount) {
+			t.Errorf("%d accounts changed in %s, want %d", len(changes[asset]), asset.Hex(), len(byAccount))
+		}
+	}
+	if len(changes) != len(want) {
+		t.Errorf("%d assets changed, want %d", len(changes), len(want))
+	}
+}
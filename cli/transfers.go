@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"go_wallet/sol"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// logRangeSize 是每次查询日志的初始区块数，节点返回结果过多时会减半重试。
+const logRangeSize = 5000
+
+// tooManyResultsErrors 是常见节点在日志查询结果过多或区块范围过大时返回的错误信息片段。
+var tooManyResultsErrors = []string{
+	"query returned more than",
+	"too many results",
+	"too many logs",
+	"limit exceeded",
+	"response size exceeded",
+	"block range",
+	"range is too large",
+}
+
+// isTooManyResults 判断日志查询是否因为结果过多或范围过大而失败。
+func isTooManyResults(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, s := range tooManyResultsErrors {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// scanRanges 将区块范围 [start, end] 按 logRangeSize 分页，依次调用 fn 查询每一页。
+// fn 返回的错误表明结果过多时，会缩小区块范围后重试。
+func scanRanges(start, end uint64, fn func(from, to uint64) error) error {
+	size := uint64(logRangeSize)
+	for from := start; from <= end; {
+		to := end
+		if end-from >= size {
+			to = from + size - 1
+		}
+		if err := fn(from, to); err != nil {
+			if isTooManyResults(err) && size > 1 {
+				size /= 2
+				continue
+			}
+			return fmt.Errorf("failed to filter logs in blocks %d-%d: %w", from, to, err)
+		}
+		if to == end {
+			break
+		}
+		from = to + 1
+	}
+	return nil
+}
+
+// filterTransferRange 在一个区块范围内分别按发送方和接收方的主题过滤 Transfer 事件，
+// 任一地址作为发送方或接收方的事件都会被返回。
+func filterTransferRange(ctx context.Context, token *sol.Token, who []common.Address, from, to uint64) ([]*sol.TokenTransfer, error) {
+	var events []*sol.TokenTransfer
+	filters := [][2][]common.Address{
+		{who, nil},
+		{nil, who},
+	}
+	for _, f := range filters {
+		it, err := token.FilterTransfer(&bind.FilterOpts{Start: from, End: &to, Context: ctx}, f[0], f[1])
+		if err != nil {
+			return nil, err
+		}
+		for it.Next() {
+			events = append(events, it.Event)
+		}
+		err = it.Error()
+		it.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// filterApprovalRange 在一个区块范围内分别按授权者和被授权者的主题过滤 Approval 事件。
+func filterApprovalRange(ctx context.Context, token *sol.Token, who []common.Address, from, to uint64) ([]*sol.TokenApproval, error) {
+	var events []*sol.TokenApproval
+	filters := [][2][]common.Address{
+		{who, nil},
+		{nil, who},
+	}
+	for _, f := range filters {
+		it, err := token.FilterApproval(&bind.FilterOpts{Start: from, End: &to, Context: ctx}, f[0], f[1])
+		if err != nil {
+			return nil, err
+		}
+		for it.Next() {
+			events = append(events, it.Event)
+		}
+		err = it.Error()
+		it.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// blockTimes 查询区块的时间戳，同一区块只查询一次。
+func blockTimes(ctx context.Context, cli *ethclient.Client, numbers []uint64) (map[uint64]time.Time, error) {
+	times := make(map[uint64]time.Time)
+	for _, n := range numbers {
+		if _, ok := times[n]; ok {
+			continue
+		}
+		header, err := cli.HeaderByNumber(ctx, new(big.Int).SetUint64(n))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get block %d: %w", n, err)
+		}
+		times[n] = time.Unix(int64(header.Time), 0).UTC()
+	}
+	return times, nil
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_wallet/hdkeystore"
+	"go_wallet/hdwallet"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// readTypedData 从文件中读取 eth_signTypedData_v4 格式的结构化数据。
+func readTypedData(file string) (apitypes.TypedData, error) {
+	var typedData apitypes.TypedData
+	if file == "" {
+		return typedData, errors.New("no typed data given, please specify -file")
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return typedData, err
+	}
+	if err := json.Unmarshal(content, &typedData); err != nil {
+		return typedData, fmt.Errorf("invalid typed data json: %w", err)
+	}
+	return typedData, nil
+}
+
+// printTypedData 打印结构化数据的字段明细以及域分隔符、消息哈希和最终签名哈希。
+func printTypedData(typedData apitypes.TypedData) error {
+	fields, err := typedData.Format()
+	if err != nil {
+		return err
+	}
+	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		return err
+	}
+	messageHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return err
+	}
+	digest, _, err := apitypes.TypedDataAndHash(typedData)
+	if err != nil {
+		return err
+	}
+	fmt.Println("========== Typed data (EIP-712) ==========")
+	for _, field := range fields {
+		fmt.Print(field.Pprint(0))
+	}
+	fmt.Println("Domain separator:", domainSeparator)
+	fmt.Println("Message hash:    ", messageHash)
+	fmt.Println("Digest:          ", hexutil.Encode(digest))
+	fmt.Println("==========================================")
+	return nil
+}
+
+// signtypeddata 使用指定账户按照 EIP-712 规则对结构化数据签名，签名前打印字段明细并要求确认。
+// 参数:
+//
+//	from - 签名账户地址。
+//	file - eth_signTypedData_v4 格式的 JSON 文件。
+//
+// 返回值:
+//
+//	string - 十六进制编码的签名。
+//	error - 如果签名过程中发生错误，则返回错误。
+func (c *Client) signtypeddata(from, file string) (string, error) {
+	typedData, err := readTypedData(file)
+	if err != nil {
+		return "", err
+	}
+	if err := printTypedData(typedData); err != nil {
+		return "", err
+	}
+	if !confirm("Sign this typed data?") {
+		return "", errors.New("signing aborted by user")
+	}
+	w, err := hdwallet.LoadWallet(from, c.dataDir)
+	if err != nil {
+		return "", err
+	}
+	sig, err := w.SignTypedData(typedData)
+	if err != nil {
+		return "", err
+	}
+	return hexutil.Encode(sig), nil
+}
+
+// verifytypeddata 从 EIP-712 签名中恢复签名者地址，
+// 如果给出了 address，则同时检查签名者是否匹配。
+// 参数:
+//
+//	address - 期望的签名者地址，可以为空。
+//	file - eth_signTypedData_v4 格式的 JSON 文件。
+//	sig - 十六进制编码的签名。
+//
+// 返回值:
+//
+//	如果签名无效或签名者不匹配，则返回错误。
+func (c *Client) verifytypeddata(address, file, sig string) error {
+	typedData, err := readTypedData(file)
+	if err != nil {
+		return err
+	}
+	sigBytes, err := hexutil.Decode(sig)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	if err := printTypedData(typedData); err != nil {
+		return err
+	}
+	signer, err := hdkeystore.RecoverTypedDataSigner(typedData, sigBytes)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Signer:", signer.Hex())
+	if address == "" {
+		return nil
+	}
+	if signer != common.HexToAddress(address) {
+		return fmt.Errorf("signature is not from %s", address)
+	}
+	fmt.Println("Signature is valid for", signer.Hex())
+	return nil
+}
@@ -0,0 +1,381 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_wallet/hdwallet"
+	"go_wallet/registry"
+	"go_wallet/sol"
+	"go_wallet/units"
+	"math/big"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	watchReorgDepth = 12              // 轮询时重新检查的最近区块数，用于发现链重组
+	watchMinBackoff = time.Second     // 重连的初始等待时间
+	watchMaxBackoff = time.Minute     // 重连的最长等待时间
+	watchBufferSize = 128             // 订阅事件通道的缓冲大小
+	watchInterval   = 5 * time.Second // 默认的 HTTP 轮询间隔
+)
+
+// watchEvent 是 watch 命令输出的一行 JSON。
+type watchEvent struct {
+	Event       string          `json:"event"` // Transfer 或 Approval
+	Token       common.Address  `json:"token"`
+	Symbol      string          `json:"symbol"`
+	From        *common.Address `json:"from,omitempty"`
+	To          *common.Address `json:"to,omitempty"`
+	Owner       *common.Address `json:"owner,omitempty"`
+	Spender     *common.Address `json:"spender,omitempty"`
+	Value       string          `json:"value"`  // 最小单位的金额
+	Amount      string          `json:"amount"` // 按代币精度格式化的金额
+	BlockNumber uint64          `json:"blockNumber"`
+	BlockHash   common.Hash     `json:"blockHash"`
+	TxHash      common.Hash     `json:"txHash"`
+	LogIndex    uint            `json:"logIndex"`
+	Removed     bool            `json:"removed"` // 为 true 时表示该事件因链重组被撤销
+}
+
+// key 返回事件在某个区块中的唯一标识。
+func (ev watchEvent) key() string {
+	return fmt.Sprintf("%s:%s:%d", ev.BlockHash.Hex(), ev.TxHash.Hex(), ev.LogIndex)
+}
+
+// watcher 保存 watch 命令的状态，订阅和轮询两种模式共用。
+type watcher struct {
+	info     registry.Token
+	accounts []common.Address
+	out      *json.Encoder
+	start    uint64                // 最早检查的区块
+	next     uint64                // 下一次轮询的起始区块
+	emitted  map[string]watchEvent // 最近输出过的事件，用于去重和检测重组
+}
+
+// newEvent 根据日志构造输出事件。
+func (w *watcher) newEvent(name string, raw types.Log, value *big.Int) watchEvent {
+	return watchEvent{
+		Event:       name,
+		Token:       w.info.Address,
+		Symbol:      w.info.Symbol,
+		Value:       value.String(),
+		Amount:      units.FormatUnits(value, int(w.info.Decimals)),
+		BlockNumber: raw.BlockNumber,
+		BlockHash:   raw.BlockHash,
+		TxHash:      raw.TxHash,
+		LogIndex:    raw.Index,
+		Removed:     raw.Removed,
+	}
+}
+
+// transferEvent 将 Transfer 事件转换为输出事件。
+func (w *watcher) transferEvent(t *sol.TokenTransfer) watchEvent {
+	ev := w.newEvent("Transfer", t.Raw, t.Value)
+	ev.From, ev.To = &t.From, &t.To
+	return ev
+}
+
+// approvalEvent 将 Approval 事件转换为输出事件。
+func (w *watcher) approvalEvent(a *sol.TokenApproval) watchEvent {
+	ev := w.newEvent("Approval", a.Raw, a.Value)
+	ev.Owner, ev.Spender = &a.Owner, &a.Spender
+	return ev
+}
+
+// handle 输出一个事件。已输出的事件不会重复输出；Removed 事件只有在之前输出过时才输出。
+func (w *watcher) handle(ev watchEvent) error {
+	key := ev.key()
+	_, seen := w.emitted[key]
+	if ev.Removed {
+		if !seen {
+			return nil
+		}
+		delete(w.emitted, key)
+	} else {
+		if seen {
+			return nil
+		}
+		w.emitted[key] = ev
+	}
+	if ev.BlockNumber >= w.next && !ev.Removed {
+		w.next = ev.BlockNumber + 1
+	}
+	return w.out.Encode(ev)
+}
+
+// poll 查询从上次位置到最新区块之间的事件。为了发现链重组，每次都会重新检查最近 watchReorgDepth 个区块：
+// 之前输出过、但在重新查询中消失的事件会以 removed 为 true 再输出一次。
+func (w *watcher) poll(ctx context.Context, cli *ethclient.Client) error {
+	head, err := cli.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+	from := w.start
+	if w.next > from+watchReorgDepth {
+		from = w.next - watchReorgDepth
+	}
+	if from > head {
+		return nil
+	}
+	token, err := sol.NewToken(w.info.Address, cli)
+	if err != nil {
+		return err
+	}
+	var found []watchEvent
+	err = scanRanges(from, head, func(a, b uint64) error {
+		transfers, err := filterTransferRange(ctx, token, w.accounts, a, b)
+		if err != nil {
+			return err
+		}
+		for _, t := range transfers {
+			found = append(found, w.transferEvent(t))
+		}
+		approvals, err := filterApprovalRange(ctx, token, w.accounts, a, b)
+		if err != nil {
+			return err
+		}
+		for _, ap := range approvals {
+			found = append(found, w.approvalEvent(ap))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].BlockNumber != found[j].BlockNumber {
+			return found[i].BlockNumber < found[j].BlockNumber
+		}
+		return found[i].LogIndex < found[j].LogIndex
+	})
+
+	present := make(map[string]bool, len(found))
+	for _, ev := range found {
+		present[ev.key()] = true
+	}
+	var removed []watchEvent
+	for key, ev := range w.emitted {
+		if ev.BlockNumber >= from && ev.BlockNumber <= head && !present[key] {
+			ev.Removed = true
+			removed = append(removed, ev)
+		}
+	}
+	sort.Slice(removed, func(i, j int) bool {
+		if removed[i].BlockNumber != removed[j].BlockNumber {
+			return removed[i].BlockNumber > removed[j].BlockNumber
+		}
+		return removed[i].LogIndex > removed[j].LogIndex
+	})
+	for _, ev := range append(removed, found...) {
+		if err := w.handle(ev); err != nil {
+			return err
+		}
+	}
+	w.advance(head)
+	return nil
+}
+
+// advance 记录已经检查到的最新区块，下一次轮询（包括重连后的补齐）从这里开始。
+// 超出重组检查范围的事件不会再被撤销，不需要继续保存。
+func (w *watcher) advance(head uint64) {
+	if head+1 > w.next {
+		w.next = head + 1
+	}
+	for key, ev := range w.emitted {
+		if ev.BlockNumber+watchReorgDepth < w.next {
+			delete(w.emitted, key)
+		}
+	}
+}
+
+// subscribe 通过 WebSocket 订阅事件，直到连接断开或 ctx 结束。
+// 订阅建立之后会先轮询一次，补齐断线期间错过的事件。同时订阅新区块，
+// 没有事件时也随链头推进下一次轮询的起始区块，并清理已输出事件的记录。
+// 返回值 connected 表示订阅是否成功建立，用于重置重连等待时间。
+func (w *watcher) subscribe(ctx context.Context, c *Client, url string) (connected bool, err error) {
+	cli, err := c.dialURL(url)
+	if err != nil {
+		return false, err
+	}
+	defer cli.Close()
+
+	filterer, err := sol.NewTokenFilterer(w.info.Address, cli)
+	if err != nil {
+		return false, err
+	}
+	transfers := make(chan *sol.TokenTransfer, watchBufferSize)
+	approvals := make(chan *sol.TokenApproval, watchBufferSize)
+	opts := &bind.WatchOpts{Context: ctx}
+	watches := []func() (event.Subscription, error){
+		func() (event.Subscription, error) { return filterer.WatchTransfer(opts, transfers, w.accounts, nil) },
+		func() (event.Subscription, error) { return filterer.WatchTransfer(opts, transfers, nil, w.accounts) },
+		func() (event.Subscription, error) { return filterer.WatchApproval(opts, approvals, w.accounts, nil) },
+		func() (event.Subscription, error) { return filterer.WatchApproval(opts, approvals, nil, w.accounts) },
+	}
+	heads := make(chan *types.Header, watchBufferSize)
+	watches = append(watches, func() (event.Subscription, error) { return cli.SubscribeNewHead(ctx, heads) })
+	errs := make(chan error, len(watches))
+	for _, watch := range watches {
+		sub, err := watch()
+		if err != nil {
+			return false, err
+		}
+		defer sub.Unsubscribe()
+		go func() {
+			if err, ok := <-sub.Err(); ok {
+				errs <- err
+			}
+		}()
+	}
+	if err := w.poll(ctx, cli); err != nil {
+		return true, err
+	}
+	fmt.Fprintln(os.Stderr, "Subscribed to", w.info.Symbol, "events via", url)
+
+	for {
+		select {
+		case t := <-transfers:
+			if err := w.handle(w.transferEvent(t)); err != nil {
+				return true, err
+			}
+		case a := <-approvals:
+			if err := w.handle(w.approvalEvent(a)); err != nil {
+				return true, err
+			}
+		case h := <-heads:
+			w.advance(h.Number.Uint64())
+		case err := <-errs:
+			return true, err
+		case <-ctx.Done():
+			return true, nil
+		}
+	}
+}
+
+// watch 实时输出与指定账户相关的代币 Transfer 和 Approval 事件，每行一个 JSON 对象。
+// 有 WebSocket 地址时使用订阅，断线后按指数退避重连；否则通过 HTTP 轮询。
+// 参数:
+//
+//	accounts - 逗号分隔的账户地址，为空时使用数据目录中的所有账户。
+//	tokenName - 代币符号或合约地址。
+//	wsURL - WebSocket 地址，为空时如果节点地址是 ws:// 或 wss:// 则使用节点地址。
+//	fromBlock - 起始区块，为 0 时从最新区块开始。
+//	interval - HTTP 轮询间隔。
+//
+// 返回值:
+//
+//	如果初始化失败，则返回错误；连接错误会重试，不会返回。
+func (c *Client) watch(accounts, tokenName, wsURL string, fromBlock uint64, interval time.Duration) error {
+	cli, err := c.dial()
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	info, err := c.resolveToken(cli, tokenName)
+	if err != nil {
+		return err
+	}
+	w := &watcher{
+		info:    info,
+		out:     json.NewEncoder(os.Stdout),
+		emitted: make(map[string]watchEvent),
+	}
+	if accounts == "" {
+		if w.accounts, err = hdwallet.ListAccounts(c.dataDir); err != nil {
+			return err
+		}
+	} else {
+		for _, a := range strings.Split(accounts, ",") {
+			a = strings.TrimSpace(a)
+			if !common.IsHexAddress(a) {
+				return fmt.Errorf("invalid address %q", a)
+			}
+			w.accounts = append(w.accounts, common.HexToAddress(a))
+		}
+	}
+	if len(w.accounts) == 0 {
+		return errors.New("no accounts to watch")
+	}
+	if fromBlock == 0 {
+		head, err := cli.BlockNumber(context.Background())
+		if err != nil {
+			return err
+		}
+		fromBlock = head + 1
+	}
+	w.start, w.next = fromBlock, fromBlock
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if wsURL == "" && (strings.HasPrefix(c.network, "ws://") || strings.HasPrefix(c.network, "wss://")) {
+		wsURL = c.network
+	}
+	fmt.Fprintf(os.Stderr, "Watching %s events of %d account(s) from block %d\n", info.Symbol, len(w.accounts), fromBlock)
+
+	backoff := watchMinBackoff
+	for wsURL != "" && ctx.Err() == nil {
+		connected, err := w.subscribe(ctx, c, wsURL)
+		if errors.Is(err, rpc.ErrNotificationsUnsupported) {
+			fmt.Fprintln(os.Stderr, "Subscriptions are not supported by", wsURL, "falling back to polling")
+			wsURL = ""
+			break
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if connected {
+			backoff = watchMinBackoff
+		}
+		fmt.Fprintf(os.Stderr, "Subscription to %s lost: %v, reconnecting in %s\n", wsURL, err, backoff)
+		if !sleepContext(ctx, backoff) {
+			return nil
+		}
+		backoff = min(backoff*2, watchMaxBackoff)
+	}
+
+	// HTTP 轮询模式。
+	for ctx.Err() == nil {
+		wait := interval
+		if err := w.poll(ctx, cli); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "Polling failed: %v, retrying in %s\n", err, backoff)
+			wait = backoff
+			backoff = min(backoff*2, watchMaxBackoff)
+		} else {
+			backoff = watchMinBackoff
+		}
+		if !sleepContext(ctx, wait) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// sleepContext 等待指定时间，ctx 结束时提前返回 false。
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
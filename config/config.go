@@ -0,0 +1,196 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"go_wallet/utils"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigFile 是未指定配置文件时默认读取的文件，不存在时使用内置配置。
+const DefaultConfigFile = "./go_wallet.yaml"
+
+// 环境变量名，优先级高于配置文件，低于命令行参数。
+const (
+	EnvConfig   = "GO_WALLET_CONFIG"
+	EnvNetwork  = "GO_WALLET_NETWORK"
+	EnvRPC      = "GO_WALLET_RPC"
+	EnvChainID  = "GO_WALLET_CHAIN_ID"
+	EnvToken    = "GO_WALLET_TOKEN"
+	EnvDataDir  = "GO_WALLET_DATADIR"
+	EnvENS      = "GO_WALLET_ENS_REGISTRY"
+	defaultName = "local"
+)
+
+// Network 是一个命名的网络配置。
+type Network struct {
+	RPC           string `yaml:"rpc"`                    // 节点的 RPC 地址
+	ChainID       uint64 `yaml:"chain_id"`               // 期望的链 ID，为 0 时使用节点返回的链 ID
+	TokenContract string `yaml:"token_contract"`         // 默认 ERC20 合约地址
+	ENSRegistry   string `yaml:"ens_registry,omitempty"` // ENS 注册表地址，为空时使用主网和测试网的统一部署地址
+}
+
+// Config 是钱包的配置，包含数据目录和若干命名的网络配置。
+type Config struct {
+	file           string              // 配置文件路径，Save 时写回该文件
+	DataDir        string              `yaml:"datadir"`         // 密钥库目录
+	DefaultNetwork string              `yaml:"default_network"` // 未指定网络时使用的网络名
+	Networks       map[string]*Network `yaml:"networks"`        // 网络名到网络配置的映射
+}
+
+// Default 返回内置的默认配置，对应本地 geth 私链。
+func Default() *Config {
+	return &Config{
+		file:           DefaultConfigFile,
+		DataDir:        "./keystore",
+		DefaultNetwork: defaultName,
+		Networks: map[string]*Network{
+			defaultName: {
+				RPC:           "http://localhost:8545",
+				ChainID:       1234567,
+				TokenContract: "0xD47497a911aD47731055BDC68718D2814d88Ff9B",
+			},
+		},
+	}
+}
+
+// Load 从 YAML 文件中加载配置。
+// 参数:
+//
+//	file - 配置文件路径，为空时依次使用环境变量 GO_WALLET_CONFIG 和 DefaultConfigFile。
+//
+// 返回值:
+//
+//	*Config - 加载的配置；默认配置文件不存在时返回内置配置。
+//	error - 如果读取或解析配置文件失败，则返回错误信息。
+func Load(file string) (*Config, error) {
+	explicit := true
+	if file == "" {
+		file = os.Getenv(EnvConfig)
+	}
+	if file == "" {
+		file, explicit = DefaultConfigFile, false
+	}
+	cfg := Default()
+	cfg.file = file
+	content, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", file, err)
+	}
+	return cfg, nil
+}
+
+// Network 返回指定名称的网络配置，名称为空时依次使用环境变量 GO_WALLET_NETWORK 和默认网络。
+func (cfg *Config) Network(name string) (string, *Network, error) {
+	if name == "" {
+		name = os.Getenv(EnvNetwork)
+	}
+	if name == "" {
+		name = cfg.DefaultNetwork
+	}
+	network, ok := cfg.Networks[name]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown network %q, available: %v", name, cfg.NetworkNames())
+	}
+	return name, network, nil
+}
+
+// NetworkNames 返回所有网络名，按字母排序。
+func (cfg *Config) NetworkNames() []string {
+	names := make([]string, 0, len(cfg.Networks))
+	for name := range cfg.Networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyEnv 使用环境变量覆盖数据目录和网络配置。
+func (cfg *Config) ApplyEnv(network *Network) error {
+	if v := os.Getenv(EnvDataDir); v != "" {
+		cfg.DataDir = v
+	}
+	if v := os.Getenv(EnvRPC); v != "" {
+		network.RPC = v
+	}
+	if v := os.Getenv(EnvToken); v != "" {
+		network.TokenContract = v
+	}
+	if v := os.Getenv(EnvENS); v != "" {
+		network.ENSRegistry = v
+	}
+	if v := os.Getenv(EnvChainID); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvChainID, err)
+		}
+		network.ChainID = id
+	}
+	return nil
+}
+
+// File 返回配置文件路径。
+func (cfg *Config) File() string {
+	return cfg.file
+}
+
+// Save 将配置写回配置文件，文件不存在时会创建。
+func (cfg *Config) Save() error {
+	content, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return utils.WriteKeyFile(cfg.file, content)
+}
+
+// SaveTokenContract 将指定网络的默认代币合约地址写入配置文件。
+// 为了不把环境变量和命令行参数的覆盖值写入文件，会重新读取配置文件，只修改这一项。
+// 参数:
+//
+//	name - 网络名。
+//	address - 代币合约地址。
+//
+// 返回值:
+//
+//	如果读取或写入配置文件失败，则返回错误信息。
+func (cfg *Config) SaveTokenContract(name, address string) error {
+	saved, err := Load(cfg.file)
+	if errors.Is(err, os.ErrNotExist) {
+		saved, err = Default(), nil
+		saved.file = cfg.file
+	}
+	if err != nil {
+		return err
+	}
+	network, ok := saved.Networks[name]
+	if !ok {
+		// 网络只存在于内存中时（例如内置配置），按当前配置补全。
+		network = &Network{}
+		if current, ok := cfg.Networks[name]; ok {
+			*network = *current
+		}
+		if saved.Networks == nil {
+			saved.Networks = map[string]*Network{}
+		}
+		saved.Networks[name] = network
+	}
+	network.TokenContract = address
+	if err := saved.Save(); err != nil {
+		return err
+	}
+	if current, ok := cfg.Networks[name]; ok {
+		current.TokenContract = address
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package contacts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go_wallet/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// FileName 是通讯录在数据目录下的文件名。
+const FileName = "contacts.json"
+
+// labelPattern 限制标签只包含字母、数字、下划线和连字符，避免与地址、ENS 名称或逗号分隔的列表混淆。
+var labelPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Contact 是通讯录中的一个联系人。
+type Contact struct {
+	Label   string         `json:"label"`
+	Address common.Address `json:"address"`
+	Note    string         `json:"note,omitempty"`
+}
+
+// Book 是本地通讯录，标签不区分大小写且不能重复，同一个地址可以有多个标签。
+type Book struct {
+	file     string
+	Contacts []Contact `json:"contacts"`
+}
+
+// Load 从文件中加载通讯录，文件不存在时返回空的通讯录。
+func Load(file string) (*Book, error) {
+	b := &Book{file: file}
+	content, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, b); err != nil {
+		return nil, fmt.Errorf("invalid address book %s: %w", file, err)
+	}
+	return b, nil
+}
+
+// Save 将通讯录写回文件。
+func (b *Book) Save() error {
+	content, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteKeyFile(b.file, content)
+}
+
+// ValidLabel 检查标签格式：1 到 64 个字母、数字、下划线或连字符，且不能以 0x 开头。
+func ValidLabel(label string) error {
+	if !labelPattern.MatchString(label) || strings.HasPrefix(strings.ToLower(label), "0x") {
+		return fmt.Errorf("invalid label %q, use 1-64 letters, digits, _ or - and do not start with 0x", label)
+	}
+	return nil
+}
+
+// Add 将联系人加入通讯录，标签已被使用时返回错误。
+func (b *Book) Add(c Contact) error {
+	if err := ValidLabel(c.Label); err != nil {
+		return err
+	}
+	if existing, ok := b.Find(c.Label); ok {
+		return fmt.Errorf("label %s is already used by %s", existing.Label, existing.Address.Hex())
+	}
+	b.Contacts = append(b.Contacts, c)
+	return nil
+}
+
+// Remove 按标签删除联系人，返回被删除的联系人。
+func (b *Book) Remove(label string) (Contact, error) {
+	for i, c := range b.Contacts {
+		if strings.EqualFold(c.Label, label) {
+			b.Contacts = append(b.Contacts[:i], b.Contacts[i+1:]...)
+			return c, nil
+		}
+	}
+	return Contact{}, fmt.Errorf("contact %s not found", label)
+}
+
+// Find 按标签（不区分大小写）查找联系人。
+func (b *Book) Find(label string) (Contact, bool) {
+	for _, c := range b.Contacts {
+		if strings.EqualFold(c.Label, label) {
+			return c, true
+		}
+	}
+	return Contact{}, false
+}
+
+// Lookup 按地址查找联系人，地址有多个标签时返回按标签排序的第一个。
+func (b *Book) Lookup(addr common.Address) (Contact, bool) {
+	for _, c := range b.List() {
+		if c.Address == addr {
+			return c, true
+		}
+	}
+	return Contact{}, false
+}
+
+// List 返回所有联系人，按标签排序。
+func (b *Book) List() []Contact {
+	list := append([]Contact(nil), b.Contacts...)
+	sort.Slice(list, func(i, j int) bool {
+		return strings.ToLower(list[i].Label) < strings.ToLower(list[j].Label)
+	})
+	return list
+}
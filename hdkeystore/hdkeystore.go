@@ -7,13 +7,16 @@ import (
 	"os"
 	"path/filepath"
 
+	"go_wallet/audit"
 	"go_wallet/utils"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 )
 
 type HDKeyStore struct {
@@ -21,6 +24,15 @@ type HDKeyStore struct {
 	scryptN     int
 	scryptP     int
 	Key         keystore.Key
+	Guard       Guard // 签名交易之前的检查，例如花费策略，为 nil 时不检查
+}
+
+// Guard 在签名交易之前检查交易，拒绝时返回错误；签名成功之后通过 Record 记录已签名的交易。
+// 检查、签名和记录期间持有 Lock 返回的锁，使并发的签名不会同时通过检查。
+type Guard interface {
+	Lock() (unlock func(), err error)
+	Check(chainID *big.Int, tx *types.Transaction) error
+	Record(chainID *big.Int, signedTx *types.Transaction) error
 }
 
 // NewHDKeyStore 创建一个新的 HDKeyStore 实例，并使用给定的私钥 ECDSA。
@@ -69,46 +81,205 @@ func (ks HDKeyStore) JoinPath(filename string) string {
 }
 
 // GetKey 从指定的文件中读取并解密密钥，并验证地址是否匹配。
+// 每次解密（无论成功与否）都会记录到审计日志。
 func (ks *HDKeyStore) GetKey(addr common.Address, filename, auth string) (*keystore.Key, error) {
 	keyjson, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
 	key, err := keystore.DecryptKey(keyjson, auth)
+	if err == nil && key.Address != addr {
+		err = fmt.Errorf("key content mismatch: have account %x, want %x", key.Address, addr)
+	}
+	if auditErr := ks.logAudit(audit.OpDecryptKey, addr, nil, common.Hash{}, err); auditErr != nil {
+		return nil, auditErr
+	}
 	if err != nil {
 		return nil, err
 	}
-	if key.Address != addr {
-		return nil, fmt.Errorf("key content mismatch: have account %x, want %x", key.Address, addr)
-	}
 	ks.Key = *key
 	return key, nil
 }
 
-// SignTx 使用当前存储的私钥对交易进行签名，并验证签名者的地址是否匹配。
+// logAudit 向数据目录下的审计日志追加一条记录，无法写入时返回错误，调用方不能返回签名结果。
+func (ks *HDKeyStore) logAudit(op string, account common.Address, chainID *big.Int, digest common.Hash, opErr error) error {
+	var id uint64
+	if chainID != nil {
+		id = chainID.Uint64()
+	}
+	if err := audit.Append(ks.keysDirPath, op, account, id, digest, opErr); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// SignTx 使用当前存储的私钥对交易进行签名，并验证签名者的地址是否匹配。支持传统交易和 EIP-2930、EIP-1559 交易。
+// 设置了 Guard 时，签名之前先由 Guard 检查交易。签名结果会记录到审计日志。
 func (ks *HDKeyStore) SignTx(account common.Address, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), ks.Key.PrivateKey)
-	if err != nil {
-		return nil, err
+	signer := types.LatestSignerForChainID(chainID)
+	signedTx, err := ks.signTx(account, tx, chainID, func(tx *types.Transaction) (*types.Transaction, error) {
+		signedTx, err := types.SignTx(tx, signer, ks.Key.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		// 使用 types.Sender 获取发送者地址
+		sender, err := types.Sender(signer, signedTx)
+		if err != nil {
+			return nil, err
+		}
+		if sender != account {
+			return nil, fmt.Errorf("signer mismatch: have account %x, want %x", sender.Hex(), account.Hex())
+		}
+		return signedTx, nil
+	})
+	digest := signer.Hash(tx)
+	if err == nil {
+		digest = signedTx.Hash()
 	}
-	// 使用 types.Sender 获取发送者地址
-	sender, err := types.Sender(types.NewEIP155Signer(chainID), signedTx)
+	if auditErr := ks.logAudit(audit.OpSignTx, account, chainID, digest, err); auditErr != nil {
+		return nil, auditErr
+	}
+	return signedTx, err
+}
+
+// signTx 在 Guard 检查通过后调用 sign 签名交易，并由 Guard 记录签名后的交易，整个过程持有 Guard 的锁。
+func (ks *HDKeyStore) signTx(account common.Address, tx *types.Transaction, chainID *big.Int, sign func(*types.Transaction) (*types.Transaction, error)) (*types.Transaction, error) {
+	if ks.Guard != nil {
+		unlock, err := ks.Guard.Lock()
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+		if err := ks.Guard.Check(chainID, tx); err != nil {
+			return nil, err
+		}
+	}
+	signedTx, err := sign(tx)
 	if err != nil {
 		return nil, err
 	}
-
-	if sender != account {
-		return nil, fmt.Errorf("signer mismatch: have account %x, want %x", sender.Hex(), account.Hex())
+	if ks.Guard != nil {
+		if err := ks.Guard.Record(chainID, signedTx); err != nil {
+			return nil, err
+		}
 	}
-
 	return signedTx, nil
 }
 
 // NewTransactOpts 创建一个新的 TransactOpts 实例，用于交易操作。
+// 合约绑定发送的每一笔交易在签名之前都会由 Guard 检查，并记录到审计日志，与 SignTx 相同。
 func (ks *HDKeyStore) NewTransactOpts(chainID *big.Int) (*bind.TransactOpts, error) {
 	opts, err := bind.NewKeyedTransactorWithChainID(ks.Key.PrivateKey, chainID)
 	if err != nil {
 		return nil, err
 	}
+	sign := opts.Signer
+	opts.Signer = func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		signedTx, err := ks.signTx(address, tx, chainID, func(tx *types.Transaction) (*types.Transaction, error) {
+			return sign(address, tx)
+		})
+		digest := types.LatestSignerForChainID(chainID).Hash(tx)
+		if err == nil {
+			digest = signedTx.Hash()
+		}
+		if auditErr := ks.logAudit(audit.OpSignTx, address, chainID, digest, err); auditErr != nil {
+			return nil, auditErr
+		}
+		return signedTx, err
+	}
 	return opts, nil
 }
+
+// SignMessage 按照 EIP-191 (personal_sign) 规则对消息签名，返回 65 字节的签名，
+// 其中 v 取值为 27 或 28，与 MetaMask 的 personal_sign 兼容。审计日志只记录消息摘要。
+func (ks *HDKeyStore) SignMessage(account common.Address, msg []byte) ([]byte, error) {
+	hash := accounts.TextHash(msg)
+	sig, err := ks.signHash(account, hash)
+	if auditErr := ks.logAudit(audit.OpSignMessage, account, nil, common.BytesToHash(hash), err); auditErr != nil {
+		return nil, auditErr
+	}
+	return sig, err
+}
+
+// signHash 使用当前存储的私钥对摘要签名，v 取值为 27 或 28。
+func (ks *HDKeyStore) signHash(account common.Address, hash []byte) ([]byte, error) {
+	if ks.Key.PrivateKey == nil {
+		return nil, fmt.Errorf("key of account %s is not loaded", account.Hex())
+	}
+	if ks.Key.Address != account {
+		return nil, fmt.Errorf("signer mismatch: have account %s, want %s", ks.Key.Address.Hex(), account.Hex())
+	}
+	sig, err := crypto.Sign(hash, ks.Key.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return sig, nil
+}
+
+// RecoverMessageSigner 从 EIP-191 (personal_sign) 签名中恢复签名者地址。
+// 签名的 v 值可以是 0/1 或 27/28。
+func RecoverMessageSigner(msg, sig []byte) (common.Address, error) {
+	return recoverSigner(accounts.TextHash(msg), sig)
+}
+
+// VerifyMessage 验证 EIP-191 (personal_sign) 签名是否由指定账户生成。
+func VerifyMessage(account common.Address, msg, sig []byte) (bool, error) {
+	signer, err := RecoverMessageSigner(msg, sig)
+	if err != nil {
+		return false, err
+	}
+	return signer == account, nil
+}
+
+// SignTypedData 按照 EIP-712 规则对结构化数据签名，返回 65 字节的签名，
+// 其中 v 取值为 27 或 28，与 eth_signTypedData_v4 兼容。审计日志只记录结构化数据的摘要。
+func (ks *HDKeyStore) SignTypedData(account common.Address, typedData apitypes.TypedData) ([]byte, error) {
+	hash, _, err := apitypes.TypedDataAndHash(typedData)
+	var sig []byte
+	if err == nil {
+		sig, err = ks.signHash(account, hash)
+	}
+	if auditErr := ks.logAudit(audit.OpSignTypedData, account, (*big.Int)(typedData.Domain.ChainId), common.BytesToHash(hash), err); auditErr != nil {
+		return nil, auditErr
+	}
+	return sig, err
+}
+
+// RecoverTypedDataSigner 从 EIP-712 签名中恢复签名者地址。
+// 签名的 v 值可以是 0/1 或 27/28。
+func RecoverTypedDataSigner(typedData apitypes.TypedData, sig []byte) (common.Address, error) {
+	hash, _, err := apitypes.TypedDataAndHash(typedData)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return recoverSigner(hash, sig)
+}
+
+// VerifyTypedData 验证 EIP-712 签名是否由指定账户生成。
+func VerifyTypedData(account common.Address, typedData apitypes.TypedData, sig []byte) (bool, error) {
+	signer, err := RecoverTypedDataSigner(typedData, sig)
+	if err != nil {
+		return false, err
+	}
+	return signer == account, nil
+}
+
+// recoverSigner 从 65 字节的签名中恢复对 hash 签名的地址。
+func recoverSigner(hash, sig []byte) (common.Address, error) {
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("invalid signature length: have %d, want %d", len(sig), crypto.SignatureLength)
+	}
+	sig = common.CopyBytes(sig)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	if sig[crypto.RecoveryIDOffset] > 1 {
+		return common.Address{}, fmt.Errorf("invalid signature recovery id: %d", sig[crypto.RecoveryIDOffset])
+	}
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	hdkeystore "go_wallet/hdkeystore"
 	"go_wallet/mnemonic"
+	"go_wallet/policy"
 	"os"
 	"path/filepath"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
 	"github.com/howeyc/gopass"
 	"github.com/tyler-smith/go-bip39"
 )
@@ -177,6 +179,10 @@ func LoadWallet(filename, datadir string) (HDWallet, error) {
 		fmt.Println("Private key is nil for address:", fromaddr.Hex())
 		return HDWallet{}, fmt.Errorf("private key is nil for address: %s", fromaddr.Hex())
 	}
+	// 加载账户的花费策略，签名交易之前检查。
+	if err := loadPolicy(hdks, datadir, fromaddr); err != nil {
+		return HDWallet{}, err
+	}
 	// 返回加载的HD钱包实例。
 	return HDWallet{
 		Address:    fromaddr,
@@ -210,9 +216,105 @@ func LoadWalletByPass(filename, datadir, pass string) (HDWallet, error) {
 		fmt.Println("Private key is nil for address:", fromaddr.Hex())
 		return HDWallet{}, fmt.Errorf("private key is nil for address: %s", fromaddr.Hex())
 	}
+	// 加载账户的花费策略，签名交易之前检查。
+	if err := loadPolicy(hdks, datadir, fromaddr); err != nil {
+		return HDWallet{}, err
+	}
 	// 返回加载的HD钱包实例。
 	return HDWallet{
 		Address:    fromaddr,
 		HDKeyStore: hdks,
 	}, nil
 }
+
+// loadPolicy 加载账户在数据目录中的花费策略，并设置为密钥库的签名检查。
+// 策略文件存在但无效时返回错误，不能在没有策略保护的情况下签名。
+func loadPolicy(hdks *hdkeystore.HDKeyStore, datadir string, account common.Address) error {
+	engine, err := policy.Load(datadir, account)
+	if err != nil {
+		return fmt.Errorf("failed to load spending policy: %w", err)
+	}
+	if engine != nil {
+		hdks.Guard = engine
+	}
+	return nil
+}
+
+// ListAccounts 列出数据目录中所有密钥文件对应的账户地址。
+// 密钥文件以账户地址命名，其他文件（例如代币注册表）会被忽略。
+// 参数:
+//
+//	datadir - 存储密钥文件的目录路径。
+//
+// 返回值:
+//
+//	[]common.Address - 账户地址列表，按文件名排序。
+//	error - 如果读取目录失败，则返回错误信息。
+func ListAccounts(datadir string) ([]common.Address, error) {
+	entries, err := os.ReadDir(datadir)
+	if err != nil {
+		return nil, err
+	}
+	var accounts []common.Address
+	for _, entry := range entries {
+		if entry.IsDir() || !common.IsHexAddress(entry.Name()) {
+			continue
+		}
+		accounts = append(accounts, common.HexToAddress(entry.Name()))
+	}
+	return accounts, nil
+}
+
+// SignMessage 使用钱包私钥按照 EIP-191 (personal_sign) 规则对消息签名。
+// 参数:
+//
+//	msg - 要签名的原始消息。
+//
+// 返回值:
+//
+//	[]byte - 65 字节的签名，v 取值为 27 或 28。
+//	error - 如果签名过程中出现错误，则返回错误信息。
+func (wallet HDWallet) SignMessage(msg []byte) ([]byte, error) {
+	return wallet.HDKeyStore.SignMessage(wallet.Address, msg)
+}
+
+// VerifyMessage 验证 EIP-191 (personal_sign) 签名是否由该钱包生成。
+// 参数:
+//
+//	msg - 原始消息。
+//	sig - 65 字节的签名。
+//
+// 返回值:
+//
+//	bool - 签名者是否为该钱包地址。
+//	error - 如果签名格式错误，则返回错误信息。
+func (wallet HDWallet) VerifyMessage(msg, sig []byte) (bool, error) {
+	return hdkeystore.VerifyMessage(wallet.Address, msg, sig)
+}
+
+// SignTypedData 使用钱包私钥按照 EIP-712 规则对结构化数据签名。
+// 参数:
+//
+//	typedData - eth_signTypedData_v4 格式的结构化数据。
+//
+// 返回值:
+//
+//	[]byte - 65 字节的签名，v 取值为 27 或 28。
+//	error - 如果签名过程中出现错误，则返回错误信息。
+func (wallet HDWallet) SignTypedData(typedData apitypes.TypedData) ([]byte, error) {
+	return wallet.HDKeyStore.SignTypedData(wallet.Address, typedData)
+}
+
+// VerifyTypedData 验证 EIP-712 签名是否由该钱包生成。
+// 参数:
+//
+//	typedData - eth_signTypedData_v4 格式的结构化数据。
+//	sig - 65 字节的签名。
+//
+// 返回值:
+//
+//	bool - 签名者是否为该钱包地址。
+//	error - 如果数据或签名格式错误，则返回错误信息。
+func (wallet HDWallet) VerifyTypedData(typedData apitypes.TypedData, sig []byte) (bool, error) {
+	return hdkeystore.VerifyTypedData(wallet.Address, typedData, sig)
+}
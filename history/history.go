@@ -0,0 +1,251 @@
+package history
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+// FileName 是历史记录数据库在数据目录下的文件名。
+const FileName = "history.db"
+
+// 记录的类型。
+const (
+	KindEther = "eth"   // 以太币交易
+	KindToken = "token" // ERC20 Transfer 事件
+)
+
+var (
+	metaKey       = []byte("meta")
+	recordsBucket = []byte("records")
+)
+
+// Record 是一条以太币交易或代币转账记录。
+type Record struct {
+	Kind        string         `json:"kind"`
+	Token       common.Address `json:"token,omitempty"` // 代币合约地址，以太币交易为空
+	BlockNumber uint64         `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	Time        uint64         `json:"time"` // 区块时间戳
+	TxHash      common.Hash    `json:"txHash"`
+	Index       uint           `json:"index"` // 以太币交易为交易序号，代币转账为日志序号
+	From        common.Address `json:"from"`
+	To          common.Address `json:"to"`
+	Value       *big.Int       `json:"value"`
+	Fee         *big.Int       `json:"fee,omitempty"`    // 以太币交易发送方支付的手续费
+	Failed      bool           `json:"failed,omitempty"` // 以太币交易是否执行失败
+}
+
+// key 返回记录在数据库中的键：区块号、类型和序号，按字节序即按链上顺序排列。
+func (r Record) key() []byte {
+	key := make([]byte, 13)
+	binary.BigEndian.PutUint64(key, r.BlockNumber)
+	if r.Kind == KindToken {
+		key[8] = 1
+	}
+	binary.BigEndian.PutUint32(key[9:], uint32(r.Index))
+	return key
+}
+
+// Meta 是某条链的索引进度。
+type Meta struct {
+	Start    uint64                    `json:"start"`    // 开始索引的区块
+	Next     uint64                    `json:"next"`     // 下一个要索引的区块
+	Accounts []common.Address          `json:"accounts"` // 被索引的账户
+	Tokens   map[common.Address]uint64 `json:"tokens"`   // 每个代币下一个要索引的区块
+	Hashes   map[uint64]common.Hash    `json:"hashes"`   // 最近索引的区块哈希，用于检测链重组
+}
+
+// Filter 是查询记录的条件，零值表示不限制。
+type Filter struct {
+	Account   *common.Address // 发送方或接收方
+	Direction string          // in 或 out，相对于 Account
+	Kind      string          // KindEther 或 KindToken
+	Token     *common.Address // 代币合约地址
+	FromBlock uint64
+	ToBlock   uint64 // 为 0 时不限制
+	FromTime  time.Time
+	ToTime    time.Time // 不包含
+}
+
+// Match 判断记录是否满足查询条件。
+func (f Filter) Match(r Record) bool {
+	if f.Kind != "" && r.Kind != f.Kind {
+		return false
+	}
+	if f.Token != nil && (r.Kind != KindToken || r.Token != *f.Token) {
+		return false
+	}
+	if r.BlockNumber < f.FromBlock || (f.ToBlock != 0 && r.BlockNumber > f.ToBlock) {
+		return false
+	}
+	t := time.Unix(int64(r.Time), 0)
+	if !f.FromTime.IsZero() && t.Before(f.FromTime) {
+		return false
+	}
+	if !f.ToTime.IsZero() && !t.Before(f.ToTime) {
+		return false
+	}
+	if f.Account != nil {
+		in, out := r.To == *f.Account, r.From == *f.Account
+		switch f.Direction {
+		case "in":
+			return in
+		case "out":
+			return out
+		default:
+			return in || out
+		}
+	}
+	return true
+}
+
+// Store 是基于 bbolt 的本地历史记录数据库，每条链使用一个以链 ID 命名的 bucket。
+type Store struct {
+	db *bolt.DB
+}
+
+// Open 打开历史记录数据库，文件不存在时会创建。
+// 参数:
+//
+//	file - 数据库文件路径。
+//
+// 返回值:
+//
+//	*Store - 数据库实例，使用完毕后需要调用 Close。
+//	error - 如果打开失败（例如被其他进程占用），则返回错误信息。
+func Open(file string) (*Store, error) {
+	db, err := bolt.Open(file, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close 关闭数据库。
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Meta 返回指定链的索引进度，尚未索引时返回 nil。
+func (s *Store) Meta(chainID *big.Int) (*Meta, error) {
+	var meta *Meta
+	err := s.db.View(func(tx *bolt.Tx) error {
+		chain := tx.Bucket([]byte(chainID.String()))
+		if chain == nil {
+			return nil
+		}
+		content := chain.Get(metaKey)
+		if content == nil {
+			return nil
+		}
+		meta = new(Meta)
+		return json.Unmarshal(content, meta)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// Commit 在一个事务中写入记录并更新索引进度。
+func (s *Store) Commit(chainID *big.Int, meta *Meta, records []Record) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		chain, err := tx.CreateBucketIfNotExists([]byte(chainID.String()))
+		if err != nil {
+			return err
+		}
+		bucket, err := chain.CreateBucketIfNotExists(recordsBucket)
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			content, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put(r.key(), content); err != nil {
+				return err
+			}
+		}
+		return putMeta(chain, meta)
+	})
+}
+
+// Rewind 删除区块号大于 ancestor 的所有记录并更新索引进度，用于处理链重组。
+func (s *Store) Rewind(chainID *big.Int, meta *Meta, ancestor uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		chain, err := tx.CreateBucketIfNotExists([]byte(chainID.String()))
+		if err != nil {
+			return err
+		}
+		if bucket := chain.Bucket(recordsBucket); bucket != nil {
+			seek := make([]byte, 8)
+			binary.BigEndian.PutUint64(seek, ancestor+1)
+			cursor := bucket.Cursor()
+			for k, _ := cursor.Seek(seek); k != nil; k, _ = cursor.Seek(seek) {
+				if err := cursor.Delete(); err != nil {
+					return err
+				}
+			}
+		}
+		return putMeta(chain, meta)
+	})
+}
+
+// Reset 删除指定链的所有记录和索引进度。
+func (s *Store) Reset(chainID *big.Int) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		err := tx.DeleteBucket([]byte(chainID.String()))
+		if errors.Is(err, bolt.ErrBucketNotFound) {
+			return nil
+		}
+		return err
+	})
+}
+
+// Query 按链上顺序返回满足条件的记录。
+func (s *Store) Query(chainID *big.Int, f Filter) ([]Record, error) {
+	var records []Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		chain := tx.Bucket([]byte(chainID.String()))
+		if chain == nil {
+			return nil
+		}
+		bucket := chain.Bucket(recordsBucket)
+		if bucket == nil {
+			return nil
+		}
+		seek := make([]byte, 8)
+		binary.BigEndian.PutUint64(seek, f.FromBlock)
+		cursor := bucket.Cursor()
+		for k, v := cursor.Seek(seek); k != nil; k, v = cursor.Next() {
+			var r Record
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			if f.ToBlock != 0 && r.BlockNumber > f.ToBlock {
+				break
+			}
+			if f.Match(r) {
+				records = append(records, r)
+			}
+		}
+		return nil
+	})
+	return records, err
+}
+
+// putMeta 将索引进度写入链的 bucket。
+func putMeta(chain *bolt.Bucket, meta *Meta) error {
+	content, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return chain.Put(metaKey, content)
+}
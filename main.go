@@ -1,10 +1,59 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+
 	client "go_wallet/cli"
+	"go_wallet/config"
 )
 
 func main() {
-	c := client.NewCmdClient("http://localhost:8545", "./keystore")
-	c.Run()
+	configFile := flag.String("config", "", "config FILE, default "+config.DefaultConfigFile)
+	networkName := flag.String("network", "", "network profile NAME in the config file")
+	rpc := flag.String("rpc", "", "RPC URL of the Ethereum node")
+	chainID := flag.Uint64("chainid", 0, "expected CHAIN ID of the network")
+	tokenContract := flag.String("tokencontract", "", "ERC20 token contract ADDRESS")
+	ensRegistry := flag.String("ensregistry", "", "ENS registry contract ADDRESS")
+	dataDir := flag.String("datadir", "", "keystore DIRECTORY")
+	flag.Parse()
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		fmt.Println("Failed to load config", err)
+		os.Exit(1)
+	}
+	name, network, err := cfg.Network(*networkName)
+	if err != nil {
+		fmt.Println("Failed to select network", err)
+		os.Exit(1)
+	}
+	if err := cfg.ApplyEnv(network); err != nil {
+		fmt.Println("Failed to load environment", err)
+		os.Exit(1)
+	}
+	// 命令行参数的优先级最高。
+	if *rpc != "" {
+		network.RPC = *rpc
+	}
+	if *chainID != 0 {
+		network.ChainID = *chainID
+	}
+	if *tokenContract != "" {
+		network.TokenContract = *tokenContract
+	}
+	if *ensRegistry != "" {
+		network.ENSRegistry = *ensRegistry
+	}
+	if *dataDir != "" {
+		cfg.DataDir = *dataDir
+	}
+
+	c, err := client.NewCmdClient(cfg, name)
+	if err != nil {
+		fmt.Println("Failed to create client", err)
+		os.Exit(1)
+	}
+	c.Run(flag.Args())
 }
@@ -0,0 +1,639 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go_wallet/units"
+	"go_wallet/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Dir 是数据目录下保存策略文件的子目录。每个账户一个策略文件，以账户地址命名，例如 policies/0xABC....json；
+// 同目录下的 0xABC....spent.json 记录该账户最近 24 小时内签名的支出，0xABC....lock 是签名期间持有的锁文件。
+const Dir = "policies"
+
+// Window 是累计限额的滚动时间窗口。
+const Window = 24 * time.Hour
+
+// lockTimeout 是等待其他进程签名完成的最长时间，超过 staleLock 未释放的锁视为进程异常退出后遗留的锁。
+const (
+	lockTimeout = 10 * time.Second
+	staleLock   = 30 * time.Second
+)
+
+// ERC20 方法选择器，用于识别代币支出和收款方。
+var (
+	transferSelector     = []byte{0xa9, 0x05, 0x9c, 0xbb} // transfer(address,uint256)
+	transferFromSelector = []byte{0x23, 0xb8, 0x72, 0xdd} // transferFrom(address,address,uint256)
+	approveSelector      = []byte{0x09, 0x5e, 0xa7, 0xb3} // approve(address,uint256)
+)
+
+// 违反策略时返回的错误类别，可以用 errors.Is 判断，具体信息通过 errors.As 取得对应的错误类型。
+var (
+	ErrPerTxLimit          = errors.New("per-transaction limit exceeded")
+	ErrDailyLimit          = errors.New("24h limit exceeded")
+	ErrRecipientDenied     = errors.New("recipient is on the deny list")
+	ErrRecipientNotAllowed = errors.New("recipient is not on the allow list")
+	ErrMethodNotAllowed    = errors.New("contract method is not allowed")
+	ErrGasPriceTooHigh     = errors.New("gas price above the cap")
+)
+
+// Limit 是一种资产的限额，金额为空表示不限制。
+type Limit struct {
+	PerTx string `json:"per_tx,omitempty"` // 单笔交易的上限
+	Daily string `json:"daily,omitempty"`  // 任意连续 24 小时内签名的累计上限
+}
+
+// TokenLimit 是一个 ERC20 代币的限额，金额按 Decimals 解析，例如 "1000.5"。
+type TokenLimit struct {
+	Token    common.Address `json:"token"`
+	Symbol   string         `json:"symbol,omitempty"` // 只用于显示
+	Decimals int            `json:"decimals"`
+	Limit
+}
+
+// Policy 是一个账户的花费策略，所有规则都是可选的。
+type Policy struct {
+	// MaxGasPrice 是 gas 价格上限，例如 "50gwei"，不带单位时按 gwei 处理，对 EIP-1559 交易限制 maxFeePerGas。
+	MaxGasPrice string `json:"max_gas_price,omitempty"`
+	// ETH 是以太币的限额，格式与 transfer 的 -value 相同，例如 "1.5ether"。
+	ETH *Limit `json:"eth,omitempty"`
+	// Tokens 是代币的限额，未列出的代币不限制金额。
+	Tokens []TokenLimit `json:"tokens,omitempty"`
+	// AllowRecipients 不为空时，只能向其中的地址转账或授权。
+	AllowRecipients []common.Address `json:"allow_recipients,omitempty"`
+	// DenyRecipients 中的地址不能作为转账或授权的对象，优先于 AllowRecipients。
+	DenyRecipients []common.Address `json:"deny_recipients,omitempty"`
+	// AllowMethods 不为空时，所有附带数据的交易都必须调用其中允许的方法。
+	// 键为合约地址或 "*"（任意合约），值为方法签名（如 transfer(address,uint256)）、4 字节选择器或 "*"（任意方法）。
+	AllowMethods map[string][]string `json:"allow_methods,omitempty"`
+	// AllowDeploy 在设置了 AllowMethods 时允许部署合约。
+	AllowDeploy bool `json:"allow_deploy,omitempty"`
+}
+
+// amountLimit 是解析后的限额，nil 表示不限制。
+type amountLimit struct {
+	label    string
+	decimals int
+	perTx    *big.Int
+	daily    *big.Int
+}
+
+// Engine 在签名之前按策略检查交易，并在签名之后记录支出，用于计算滚动 24 小时限额。
+type Engine struct {
+	account     common.Address
+	policy      Policy
+	maxGasPrice *big.Int
+	limits      map[common.Address]*amountLimit // 零地址表示以太币
+	allow       map[common.Address]bool
+	deny        map[common.Address]bool
+	methods     map[string]map[string]bool // 合约地址（小写）或 "*" 到选择器（十六进制）或 "*" 的集合
+	ledger      *Ledger
+	lockFile    string     // 为空时只在进程内互斥
+	mu          sync.Mutex // 进程内的并发签名
+
+	// Now 返回当前时间，测试时可以替换。
+	Now func() time.Time
+}
+
+// File 返回账户策略文件的路径。
+func File(datadir string, account common.Address) string {
+	return filepath.Join(datadir, Dir, account.Hex()+".json")
+}
+
+// ledgerFile 返回账户支出记录文件的路径。
+func ledgerFile(datadir string, account common.Address) string {
+	return filepath.Join(datadir, Dir, account.Hex()+".spent.json")
+}
+
+// lockFile 返回账户签名锁文件的路径。
+func lockFile(datadir string, account common.Address) string {
+	return filepath.Join(datadir, Dir, account.Hex()+".lock")
+}
+
+// Load 加载数据目录中账户的策略文件，账户没有策略文件时返回 nil。
+// 参数:
+//
+//	datadir - 数据目录。
+//	account - 账户地址。
+//
+// 返回值:
+//
+//	*Engine - 策略引擎，账户没有策略时为 nil。
+//	error - 如果策略文件或支出记录无效，则返回错误信息；策略无法加载时不应继续签名。
+func Load(datadir string, account common.Address) (*Engine, error) {
+	file := File(datadir, account)
+	content, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var p Policy
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&p); err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", file, err)
+	}
+	ledger, err := LoadLedger(ledgerFile(datadir, account))
+	if err != nil {
+		return nil, err
+	}
+	e, err := New(account, p, ledger)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", file, err)
+	}
+	e.lockFile = lockFile(datadir, account)
+	return e, nil
+}
+
+// New 创建账户的策略引擎。ledger 为 nil 时使用只保存在内存中的支出记录。
+func New(account common.Address, p Policy, ledger *Ledger) (*Engine, error) {
+	if ledger == nil {
+		ledger = &Ledger{}
+	}
+	e := &Engine{
+		account: account,
+		policy:  p,
+		limits:  make(map[common.Address]*amountLimit),
+		allow:   make(map[common.Address]bool),
+		deny:    make(map[common.Address]bool),
+		methods: make(map[string]map[string]bool),
+		ledger:  ledger,
+		Now:     time.Now,
+	}
+	var err error
+	if p.MaxGasPrice != "" {
+		price := p.MaxGasPrice
+		if strings.TrimLeft(price, "0123456789.") == "" {
+			price += "gwei"
+		}
+		if e.maxGasPrice, err = units.ParseEther(price); err != nil {
+			return nil, fmt.Errorf("max_gas_price: %w", err)
+		}
+	}
+	if p.ETH != nil {
+		limit, err := parseLimit("ETH", units.EtherDecimals, *p.ETH, units.ParseEther)
+		if err != nil {
+			return nil, fmt.Errorf("eth: %w", err)
+		}
+		e.limits[common.Address{}] = limit
+	}
+	for _, t := range p.Tokens {
+		if t.Token == (common.Address{}) {
+			return nil, errors.New("tokens: missing token address")
+		}
+		if _, ok := e.limits[t.Token]; ok {
+			return nil, fmt.Errorf("tokens: duplicate token %s", t.Token.Hex())
+		}
+		label := t.Symbol
+		if label == "" {
+			label = t.Token.Hex()
+		}
+		decimals := t.Decimals
+		limit, err := parseLimit(label, decimals, t.Limit, func(s string) (*big.Int, error) {
+			return units.ParseUnits(s, decimals)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("tokens: %s: %w", label, err)
+		}
+		e.limits[t.Token] = limit
+	}
+	for _, addr := range p.AllowRecipients {
+		e.allow[addr] = true
+	}
+	for _, addr := range p.DenyRecipients {
+		e.deny[addr] = true
+	}
+	for contract, list := range p.AllowMethods {
+		key := strings.ToLower(contract)
+		if key != "*" && !common.IsHexAddress(key) {
+			return nil, fmt.Errorf("allow_methods: invalid contract %q, expected an address or *", contract)
+		}
+		if key != "*" {
+			key = strings.ToLower(common.HexToAddress(key).Hex())
+		}
+		if e.methods[key] == nil {
+			e.methods[key] = make(map[string]bool)
+		}
+		for _, m := range list {
+			selector, err := parseSelector(m)
+			if err != nil {
+				return nil, fmt.Errorf("allow_methods: %s: %w", contract, err)
+			}
+			e.methods[key][selector] = true
+		}
+	}
+	return e, nil
+}
+
+// parseLimit 按 parse 解析限额中的金额。
+func parseLimit(label string, decimals int, l Limit, parse func(string) (*big.Int, error)) (*amountLimit, error) {
+	limit := &amountLimit{label: label, decimals: decimals}
+	var err error
+	if l.PerTx != "" {
+		if limit.perTx, err = parse(l.PerTx); err != nil {
+			return nil, fmt.Errorf("per_tx: %w", err)
+		}
+	}
+	if l.Daily != "" {
+		if limit.daily, err = parse(l.Daily); err != nil {
+			return nil, fmt.Errorf("daily: %w", err)
+		}
+	}
+	return limit, nil
+}
+
+// parseSelector 将方法签名或 4 字节选择器转换为十六进制选择器，"*" 原样返回。
+func parseSelector(m string) (string, error) {
+	m = strings.TrimSpace(m)
+	if m == "*" {
+		return m, nil
+	}
+	if strings.HasPrefix(m, "0x") {
+		b, err := hexutil.Decode(m)
+		if err != nil || len(b) != 4 {
+			return "", fmt.Errorf("invalid selector %q, expected 4 bytes", m)
+		}
+		return hexutil.Encode(b), nil
+	}
+	if !strings.Contains(m, "(") || !strings.HasSuffix(m, ")") {
+		return "", fmt.Errorf("invalid method %q, expected a signature like transfer(address,uint256) or a selector", m)
+	}
+	return hexutil.Encode(crypto.Keccak256([]byte(strings.ReplaceAll(m, " ", "")))[:4]), nil
+}
+
+// Account 返回策略所属的账户。
+func (e *Engine) Account() common.Address {
+	return e.account
+}
+
+// Policy 返回策略的配置。
+func (e *Engine) Policy() Policy {
+	return e.policy
+}
+
+// spend 是交易转出的一笔资产，asset 为零地址表示以太币。
+type spend struct {
+	asset  common.Address
+	amount *big.Int
+}
+
+// inspect 从交易中找出收款方（以太币接收方、代币接收方或被授权方）和转出的资产。
+func inspect(tx *types.Transaction) (recipients []common.Address, spends []spend) {
+	if tx.Value().Sign() > 0 {
+		spends = append(spends, spend{amount: tx.Value()})
+		if tx.To() != nil {
+			recipients = append(recipients, *tx.To())
+		}
+	}
+	data := tx.Data()
+	if tx.To() == nil || len(data) < 4 {
+		return recipients, spends
+	}
+	word := func(i int) []byte {
+		if len(data) < 4+32*(i+1) {
+			return nil
+		}
+		return data[4+32*i : 4+32*(i+1)]
+	}
+	switch {
+	case bytes.Equal(data[:4], transferSelector) && word(1) != nil:
+		recipients = append(recipients, common.BytesToAddress(word(0)))
+		spends = append(spends, spend{asset: *tx.To(), amount: new(big.Int).SetBytes(word(1))})
+	case bytes.Equal(data[:4], transferFromSelector) && word(2) != nil:
+		recipients = append(recipients, common.BytesToAddress(word(1)))
+		spends = append(spends, spend{asset: *tx.To(), amount: new(big.Int).SetBytes(word(2))})
+	case bytes.Equal(data[:4], approveSelector) && word(1) != nil:
+		recipients = append(recipients, common.BytesToAddress(word(0)))
+	}
+	return recipients, spends
+}
+
+// Lock 获取账户的签名锁，返回释放锁的函数。检查、签名和记录支出都应在持有锁时进行，
+// 否则多个进程（例如 serve 和命令行转账）可能同时通过累计限额的检查。
+func (e *Engine) Lock() (func(), error) {
+	e.mu.Lock()
+	if e.lockFile == "" {
+		return e.mu.Unlock, nil
+	}
+	unlock, err := utils.LockFile(e.lockFile, lockTimeout, staleLock)
+	if err != nil {
+		e.mu.Unlock()
+		if errors.Is(err, utils.ErrLocked) {
+			return nil, fmt.Errorf("spending policy of %s is locked by another signer, remove %s if no other wallet is running", e.account.Hex(), e.lockFile)
+		}
+		return nil, err
+	}
+	return func() {
+		unlock()
+		e.mu.Unlock()
+	}, nil
+}
+
+// Check 按策略检查待签名的交易，违反策略时返回对应类型的错误。
+// 参数:
+//
+//	chainID - 交易所在链的 ID，累计限额按链分别计算。
+//	tx - 待签名的交易。
+//
+// 返回值:
+//
+//	*GasPriceError、*MethodError、*RecipientError 或 *LimitError；读取支出记录失败时返回其他错误。
+func (e *Engine) Check(chainID *big.Int, tx *types.Transaction) error {
+	if e.maxGasPrice != nil && tx.GasFeeCap().Cmp(e.maxGasPrice) > 0 {
+		return &GasPriceError{Account: e.account, GasPrice: tx.GasFeeCap(), Cap: e.maxGasPrice}
+	}
+	if err := e.checkMethod(tx); err != nil {
+		return err
+	}
+	recipients, spends := inspect(tx)
+	for _, r := range recipients {
+		if e.deny[r] {
+			return &RecipientError{Account: e.account, Recipient: r, Denied: true}
+		}
+		if len(e.allow) > 0 && !e.allow[r] {
+			return &RecipientError{Account: e.account, Recipient: r}
+		}
+	}
+	if err := e.ledger.reload(); err != nil {
+		return err
+	}
+	since := e.Now().Add(-Window)
+	for _, s := range spends {
+		limit, ok := e.limits[s.asset]
+		if !ok {
+			continue
+		}
+		if limit.perTx != nil && s.amount.Cmp(limit.perTx) > 0 {
+			return &LimitError{Account: e.account, Asset: s.asset, Label: limit.label, Decimals: limit.decimals, Amount: s.amount, Limit: limit.perTx}
+		}
+		if limit.daily != nil {
+			spent := e.ledger.Spent(chainID, s.asset, since)
+			if new(big.Int).Add(spent, s.amount).Cmp(limit.daily) > 0 {
+				return &LimitError{Account: e.account, Asset: s.asset, Label: limit.label, Decimals: limit.decimals, Amount: s.amount, Limit: limit.daily, Spent: spent, Daily: true}
+			}
+		}
+	}
+	return nil
+}
+
+// checkMethod 在设置了方法白名单时检查交易调用的方法。
+func (e *Engine) checkMethod(tx *types.Transaction) error {
+	if len(e.methods) == 0 {
+		return nil
+	}
+	if tx.To() == nil {
+		if e.policy.AllowDeploy {
+			return nil
+		}
+		return &MethodError{Account: e.account}
+	}
+	data := tx.Data()
+	if len(data) == 0 {
+		return nil
+	}
+	contract := *tx.To()
+	if len(data) < 4 {
+		return &MethodError{Account: e.account, Contract: &contract, Data: data}
+	}
+	selector := hexutil.Encode(data[:4])
+	for _, key := range []string{strings.ToLower(contract.Hex()), "*"} {
+		if allowed := e.methods[key]; allowed["*"] || allowed[selector] {
+			return nil
+		}
+	}
+	return &MethodError{Account: e.account, Contract: &contract, Data: data}
+}
+
+// Record 在交易签名之后记录其中受限额约束的支出。同一笔交易重复签名只记录一次。
+func (e *Engine) Record(chainID *big.Int, tx *types.Transaction) error {
+	_, spends := inspect(tx)
+	if err := e.ledger.reload(); err != nil {
+		return err
+	}
+	now := e.Now()
+	changed := false
+	for _, s := range spends {
+		if _, ok := e.limits[s.asset]; !ok || e.ledger.has(tx.Hash(), s.asset) {
+			continue
+		}
+		e.ledger.Spends = append(e.ledger.Spends, Spend{
+			Time:    now.Unix(),
+			ChainID: chainID.Uint64(),
+			Asset:   s.asset,
+			Amount:  s.amount,
+			TxHash:  tx.Hash(),
+		})
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	e.ledger.prune(now.Add(-Window))
+	return e.ledger.Save()
+}
+
+// Usage 返回资产在最近 24 小时内已签名的累计支出，asset 为零地址表示以太币。
+func (e *Engine) Usage(chainID *big.Int, asset common.Address) (*big.Int, error) {
+	if err := e.ledger.reload(); err != nil {
+		return nil, err
+	}
+	return e.ledger.Spent(chainID, asset, e.Now().Add(-Window)), nil
+}
+
+// Spend 是支出记录中的一笔支出。
+type Spend struct {
+	Time    int64          `json:"time"` // 签名时间，Unix 秒
+	ChainID uint64         `json:"chain_id"`
+	Asset   common.Address `json:"asset"` // 代币合约地址，以太币为零地址
+	Amount  *big.Int       `json:"amount"`
+	TxHash  common.Hash    `json:"tx"`
+}
+
+// Ledger 是账户最近签名的支出记录，超过 24 小时的记录在保存时删除。
+// 签名之后即记录支出，即使交易最终没有被广播或被打包。
+type Ledger struct {
+	file   string
+	Spends []Spend `json:"spends"`
+}
+
+// LoadLedger 从文件中加载支出记录，文件不存在时返回空的记录；file 为空时只保存在内存中。
+func LoadLedger(file string) (*Ledger, error) {
+	l := &Ledger{file: file}
+	if err := l.reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// reload 重新读取支出记录文件，使多个进程签名的支出都能计入限额。
+func (l *Ledger) reload() error {
+	if l.file == "" {
+		return nil
+	}
+	content, err := os.ReadFile(l.file)
+	if errors.Is(err, os.ErrNotExist) {
+		l.Spends = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var saved Ledger
+	if err := json.Unmarshal(content, &saved); err != nil {
+		return fmt.Errorf("invalid spending ledger %s: %w", l.file, err)
+	}
+	l.Spends = saved.Spends
+	return nil
+}
+
+// Save 将支出记录写回文件。
+func (l *Ledger) Save() error {
+	if l.file == "" {
+		return nil
+	}
+	content, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteKeyFile(l.file, content)
+}
+
+// Spent 返回 since 之后签名的资产支出之和。
+func (l *Ledger) Spent(chainID *big.Int, asset common.Address, since time.Time) *big.Int {
+	total := new(big.Int)
+	for _, s := range l.Spends {
+		if s.ChainID == chainID.Uint64() && s.Asset == asset && s.Time > since.Unix() {
+			total.Add(total, s.Amount)
+		}
+	}
+	return total
+}
+
+// has 判断交易的某项支出是否已经记录。
+func (l *Ledger) has(hash common.Hash, asset common.Address) bool {
+	for _, s := range l.Spends {
+		if s.TxHash == hash && s.Asset == asset {
+			return true
+		}
+	}
+	return false
+}
+
+// prune 删除 before 之前的记录，并按时间排序。
+func (l *Ledger) prune(before time.Time) {
+	kept := l.Spends[:0]
+	for _, s := range l.Spends {
+		if s.Time > before.Unix() {
+			kept = append(kept, s)
+		}
+	}
+	l.Spends = kept
+	sort.SliceStable(l.Spends, func(i, j int) bool { return l.Spends[i].Time < l.Spends[j].Time })
+}
+
+// formatAmount 按精度格式化金额并附上资产名称。
+func formatAmount(amount *big.Int, decimals int, label string) string {
+	return units.FormatUnits(amount, decimals) + " " + label
+}
+
+// LimitError 表示交易金额超过单笔限额或滚动 24 小时限额。
+type LimitError struct {
+	Account  common.Address
+	Asset    common.Address // 代币合约地址，以太币为零地址
+	Label    string         // 资产名称
+	Decimals int
+	Amount   *big.Int // 本笔交易的金额
+	Limit    *big.Int
+	Spent    *big.Int // 最近 24 小时内已签名的金额，只在 Daily 为 true 时有效
+	Daily    bool
+}
+
+func (e *LimitError) Error() string {
+	if e.Daily {
+		return fmt.Sprintf("policy of %s: %v: %s already signed in the last 24h, plus %s would exceed %s",
+			e.Account.Hex(), ErrDailyLimit, formatAmount(e.Spent, e.Decimals, e.Label),
+			formatAmount(e.Amount, e.Decimals, e.Label), formatAmount(e.Limit, e.Decimals, e.Label))
+	}
+	return fmt.Sprintf("policy of %s: %v: %s is more than %s", e.Account.Hex(), ErrPerTxLimit,
+		formatAmount(e.Amount, e.Decimals, e.Label), formatAmount(e.Limit, e.Decimals, e.Label))
+}
+
+func (e *LimitError) Unwrap() error {
+	if e.Daily {
+		return ErrDailyLimit
+	}
+	return ErrPerTxLimit
+}
+
+// RecipientError 表示收款方在黑名单中，或设置了白名单而收款方不在其中。
+type RecipientError struct {
+	Account   common.Address
+	Recipient common.Address
+	Denied    bool // true 表示在黑名单中
+}
+
+func (e *RecipientError) Error() string {
+	return fmt.Sprintf("policy of %s: %v: %s", e.Account.Hex(), e.Unwrap(), e.Recipient.Hex())
+}
+
+func (e *RecipientError) Unwrap() error {
+	if e.Denied {
+		return ErrRecipientDenied
+	}
+	return ErrRecipientNotAllowed
+}
+
+// MethodError 表示交易调用的方法不在白名单中，Contract 为 nil 表示部署合约。
+type MethodError struct {
+	Account  common.Address
+	Contract *common.Address
+	Data     []byte
+}
+
+func (e *MethodError) Error() string {
+	if e.Contract == nil {
+		return fmt.Sprintf("policy of %s: %v: contract deployment", e.Account.Hex(), ErrMethodNotAllowed)
+	}
+	selector := hexutil.Encode(e.Data)
+	if len(e.Data) >= 4 {
+		selector = hexutil.Encode(e.Data[:4])
+	}
+	return fmt.Sprintf("policy of %s: %v: selector %s on %s", e.Account.Hex(), ErrMethodNotAllowed, selector, e.Contract.Hex())
+}
+
+func (e *MethodError) Unwrap() error {
+	return ErrMethodNotAllowed
+}
+
+// GasPriceError 表示交易的 gas 价格（EIP-1559 交易为 maxFeePerGas）超过上限。
+type GasPriceError struct {
+	Account  common.Address
+	GasPrice *big.Int
+	Cap      *big.Int
+}
+
+func (e *GasPriceError) Error() string {
+	return fmt.Sprintf("policy of %s: %v: %s gwei is more than %s gwei", e.Account.Hex(), ErrGasPriceTooHigh,
+		units.FormatUnits(e.GasPrice, 9), units.FormatUnits(e.Cap, 9))
+}
+
+func (e *GasPriceError) Unwrap() error {
+	return ErrGasPriceTooHigh
+}
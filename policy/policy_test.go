@@ -0,0 +1,406 @@
+package policy
+
+import (
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	chainID   = big.NewInt(1)
+	account   = common.HexToAddress("0x2c7536E3605D9C16a7a3D7b1898e529396a65c23")
+	recipient = common.HexToAddress("0x703c4b2bD70c169f5717101CaeE543299Fc946C7")
+	stranger  = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	token     = common.HexToAddress("0xB737a04E639E9498cec7020d6D82c80D88853131")
+)
+
+func ether(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(params.Ether))
+}
+
+func gwei(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(params.GWei))
+}
+
+// etherTx 返回向 to 转账 value 的传统交易，nonce 用于区分交易哈希。
+func etherTx(nonce uint64, to common.Address, value *big.Int) *types.Transaction {
+	return types.NewTx(&types.LegacyTx{Nonce: nonce, To: &to, Value: value, Gas: 21000, GasPrice: gwei(1)})
+}
+
+// callTx 返回调用合约方法的传统交易，args 为 32 字节的参数。
+func callTx(nonce uint64, contract common.Address, selector []byte, args ...[]byte) *types.Transaction {
+	data := append([]byte{}, selector...)
+	for _, a := range args {
+		data = append(data, common.LeftPadBytes(a, 32)...)
+	}
+	return types.NewTx(&types.LegacyTx{Nonce: nonce, To: &contract, Gas: 100000, GasPrice: gwei(1), Data: data})
+}
+
+// tokenTransfer 返回向 to 转账 amount 个代币最小单位的交易。
+func tokenTransfer(nonce uint64, to common.Address, amount int64) *types.Transaction {
+	return callTx(nonce, token, transferSelector, to.Bytes(), big.NewInt(amount).Bytes())
+}
+
+// newEngine 创建使用内存支出记录的策略引擎，时间由返回的指针控制。
+func newEngine(t *testing.T, p Policy) (*Engine, *time.Time) {
+	t.Helper()
+	e, err := New(account, p, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	e.Now = func() time.Time { return now }
+	return e, &now
+}
+
+// sign 检查交易，通过后记录支出。
+func sign(e *Engine, tx *types.Transaction) error {
+	if err := e.Check(chainID, tx); err != nil {
+		return err
+	}
+	return e.Record(chainID, tx)
+}
+
+func TestPerTxLimit(t *testing.T) {
+	e, _ := newEngine(t, Policy{
+		ETH:    &Limit{PerTx: "1ether"},
+		Tokens: []TokenLimit{{Token: token, Symbol: "TKN", Decimals: 2, Limit: Limit{PerTx: "5"}}},
+	})
+	if err := e.Check(chainID, etherTx(0, recipient, ether(1))); err != nil {
+		t.Fatalf("transfer at the limit rejected: %v", err)
+	}
+	err := e.Check(chainID, etherTx(0, recipient, new(big.Int).Add(ether(1), big.NewInt(1))))
+	var limitErr *LimitError
+	if !errors.Is(err, ErrPerTxLimit) || !errors.As(err, &limitErr) || limitErr.Daily || limitErr.Asset != (common.Address{}) {
+		t.Fatalf("Check returned %v, want a per-tx ETH LimitError", err)
+	}
+
+	// 代币金额按 decimals 解析，5 TKN 为 500 个最小单位。
+	if err := e.Check(chainID, tokenTransfer(0, recipient, 500)); err != nil {
+		t.Fatalf("token transfer at the limit rejected: %v", err)
+	}
+	err = e.Check(chainID, tokenTransfer(0, recipient, 501))
+	if !errors.Is(err, ErrPerTxLimit) || !errors.As(err, &limitErr) || limitErr.Asset != token || limitErr.Label != "TKN" {
+		t.Fatalf("Check returned %v, want a per-tx token LimitError", err)
+	}
+
+	// 未列出的代币不限制金额。
+	other := callTx(0, stranger, transferSelector, recipient.Bytes(), ether(1000).Bytes())
+	if err := e.Check(chainID, other); err != nil {
+		t.Fatalf("transfer of an unlisted token rejected: %v", err)
+	}
+}
+
+func TestDailyLimitWindow(t *testing.T) {
+	e, now := newEngine(t, Policy{ETH: &Limit{Daily: "3ether"}})
+	start := *now
+	if err := sign(e, etherTx(0, recipient, ether(2))); err != nil {
+		t.Fatal(err)
+	}
+	*now = start.Add(12 * time.Hour)
+	if err := sign(e, etherTx(1, recipient, ether(1))); err != nil {
+		t.Fatal(err)
+	}
+
+	// 24 小时内已签名 3 ETH，再签名任何金额都超过限额。
+	*now = start.Add(Window - time.Second)
+	err := e.Check(chainID, etherTx(2, recipient, big.NewInt(1)))
+	var limitErr *LimitError
+	if !errors.Is(err, ErrDailyLimit) || !errors.As(err, &limitErr) || !limitErr.Daily || limitErr.Spent.Cmp(ether(3)) != 0 {
+		t.Fatalf("Check returned %v, want a daily LimitError with 3 ETH spent", err)
+	}
+	if errors.Is(err, ErrPerTxLimit) {
+		t.Fatal("daily limit error matches ErrPerTxLimit")
+	}
+
+	// 第一笔支出正好滑出窗口，可以再签名 2 ETH，但不能超过。
+	*now = start.Add(Window)
+	if err := e.Check(chainID, etherTx(2, recipient, new(big.Int).Add(ether(2), big.NewInt(1)))); !errors.Is(err, ErrDailyLimit) {
+		t.Fatalf("Check returned %v, want ErrDailyLimit", err)
+	}
+	if err := sign(e, etherTx(2, recipient, ether(2))); err != nil {
+		t.Fatalf("transfer after the window moved rejected: %v", err)
+	}
+	if usage, err := e.Usage(chainID, common.Address{}); err != nil || usage.Cmp(ether(3)) != 0 {
+		t.Fatalf("Usage = %v, %v, want 3 ETH", usage, err)
+	}
+
+	// 累计限额按链分别计算。
+	if err := e.Check(big.NewInt(5), etherTx(3, recipient, ether(3))); err != nil {
+		t.Fatalf("transfer on another chain rejected: %v", err)
+	}
+}
+
+func TestRecordDedup(t *testing.T) {
+	e, _ := newEngine(t, Policy{ETH: &Limit{Daily: "3ether"}})
+	tx := etherTx(0, recipient, ether(2))
+	for i := 0; i < 3; i++ {
+		if err := e.Record(chainID, tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if usage, err := e.Usage(chainID, common.Address{}); err != nil || usage.Cmp(ether(2)) != 0 {
+		t.Fatalf("Usage = %v, %v, want 2 ETH after recording the same transaction 3 times", usage, err)
+	}
+	if err := e.Record(chainID, etherTx(1, recipient, ether(1))); err != nil {
+		t.Fatal(err)
+	}
+	if usage, _ := e.Usage(chainID, common.Address{}); usage.Cmp(ether(3)) != 0 {
+		t.Fatalf("Usage = %v, want 3 ETH", usage)
+	}
+}
+
+func TestRecipients(t *testing.T) {
+	e, _ := newEngine(t, Policy{
+		AllowRecipients: []common.Address{recipient, stranger},
+		DenyRecipients:  []common.Address{stranger},
+	})
+	if err := e.Check(chainID, etherTx(0, recipient, ether(1))); err != nil {
+		t.Fatalf("allowed recipient rejected: %v", err)
+	}
+
+	// 黑名单优先于白名单。
+	err := e.Check(chainID, etherTx(0, stranger, ether(1)))
+	var recipientErr *RecipientError
+	if !errors.Is(err, ErrRecipientDenied) || !errors.As(err, &recipientErr) || !recipientErr.Denied || recipientErr.Recipient != stranger {
+		t.Fatalf("Check returned %v, want a denied RecipientError", err)
+	}
+
+	// 代币转账和授权检查的是调用数据中的接收方和被授权方，而不是合约地址。
+	other := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	tests := []*types.Transaction{
+		etherTx(0, other, ether(1)),
+		tokenTransfer(0, other, 1),
+		callTx(0, token, transferFromSelector, recipient.Bytes(), other.Bytes(), big.NewInt(1).Bytes()),
+		callTx(0, token, approveSelector, other.Bytes(), big.NewInt(1).Bytes()),
+	}
+	for i, tx := range tests {
+		err := e.Check(chainID, tx)
+		if !errors.Is(err, ErrRecipientNotAllowed) || !errors.As(err, &recipientErr) || recipientErr.Denied || recipientErr.Recipient != other {
+			t.Errorf("test %d: Check returned %v, want a not allowed RecipientError", i, err)
+		}
+	}
+	if err := e.Check(chainID, tokenTransfer(0, recipient, 1)); err != nil {
+		t.Fatalf("token transfer to an allowed recipient rejected: %v", err)
+	}
+}
+
+func TestAllowMethods(t *testing.T) {
+	approve := "approve(address,uint256)"
+	e, _ := newEngine(t, Policy{
+		AllowMethods: map[string][]string{
+			token.Hex():    {"transfer(address, uint256)", "0x095ea7b3"},
+			"*":            {"deposit()"},
+			stranger.Hex(): {"*"},
+		},
+	})
+	deposit := crypto.Keccak256([]byte("deposit()"))[:4]
+	allowed := []*types.Transaction{
+		tokenTransfer(0, recipient, 1),
+		callTx(0, token, approveSelector, recipient.Bytes(), big.NewInt(1).Bytes()),
+		callTx(0, recipient, deposit),
+		callTx(0, stranger, []byte{0xde, 0xad, 0xbe, 0xef}),
+		etherTx(0, recipient, ether(1)), // 不带数据的转账不受方法白名单限制
+	}
+	for i, tx := range allowed {
+		if err := e.Check(chainID, tx); err != nil {
+			t.Errorf("allowed call %d rejected: %v", i, err)
+		}
+	}
+
+	var methodErr *MethodError
+	denied := []*types.Transaction{
+		callTx(0, token, transferFromSelector, recipient.Bytes(), recipient.Bytes(), big.NewInt(1).Bytes()),
+		callTx(0, recipient, approveSelector, recipient.Bytes(), big.NewInt(1).Bytes()),
+		types.NewTx(&types.LegacyTx{To: &token, Gas: 100000, GasPrice: gwei(1), Data: []byte{0x01, 0x02}}),
+	}
+	for i, tx := range denied {
+		err := e.Check(chainID, tx)
+		if !errors.Is(err, ErrMethodNotAllowed) || !errors.As(err, &methodErr) || methodErr.Contract == nil || *methodErr.Contract != *tx.To() {
+			t.Errorf("call %d: Check returned %v, want a MethodError", i, err)
+		}
+	}
+
+	// 设置了方法白名单时，部署合约需要 allow_deploy。
+	deploy := types.NewTx(&types.LegacyTx{Gas: 1000000, GasPrice: gwei(1), Data: []byte{0x60, 0x80}})
+	err := e.Check(chainID, deploy)
+	if !errors.Is(err, ErrMethodNotAllowed) || !errors.As(err, &methodErr) || methodErr.Contract != nil {
+		t.Fatalf("Check returned %v, want a deployment MethodError", err)
+	}
+	p := e.Policy()
+	p.AllowDeploy = true
+	if e, _ = newEngine(t, p); e.Check(chainID, deploy) != nil {
+		t.Fatal("deployment rejected with allow_deploy")
+	}
+
+	// 没有方法白名单时不限制调用和部署。
+	e, _ = newEngine(t, Policy{})
+	if err := e.Check(chainID, deploy); err != nil {
+		t.Fatalf("deployment rejected without allow_methods: %v", err)
+	}
+	if _, err := New(account, Policy{AllowMethods: map[string][]string{"token": {approve}}}, nil); err == nil {
+		t.Fatal("invalid contract key accepted")
+	}
+	if _, err := New(account, Policy{AllowMethods: map[string][]string{"*": {"approve"}}}, nil); err == nil {
+		t.Fatal("invalid method accepted")
+	}
+}
+
+func TestGasPriceCap(t *testing.T) {
+	e, _ := newEngine(t, Policy{MaxGasPrice: "50"})
+	legacy := func(price *big.Int) *types.Transaction {
+		return types.NewTx(&types.LegacyTx{To: &recipient, Gas: 21000, GasPrice: price})
+	}
+	dynamic := func(feeCap, tipCap *big.Int) *types.Transaction {
+		return types.NewTx(&types.DynamicFeeTx{ChainID: chainID, To: &recipient, Gas: 21000, GasFeeCap: feeCap, GasTipCap: tipCap})
+	}
+	if err := e.Check(chainID, legacy(gwei(50))); err != nil {
+		t.Fatalf("legacy transaction at the cap rejected: %v", err)
+	}
+	if err := e.Check(chainID, dynamic(gwei(50), gwei(2))); err != nil {
+		t.Fatalf("1559 transaction at the cap rejected: %v", err)
+	}
+
+	var gasErr *GasPriceError
+	// EIP-1559 交易按 maxFeePerGas 判断，即使小费很低。
+	for i, tx := range []*types.Transaction{legacy(gwei(51)), dynamic(gwei(51), gwei(1))} {
+		err := e.Check(chainID, tx)
+		if !errors.Is(err, ErrGasPriceTooHigh) || !errors.As(err, &gasErr) || gasErr.GasPrice.Cmp(gwei(51)) != 0 || gasErr.Cap.Cmp(gwei(50)) != 0 {
+			t.Errorf("test %d: Check returned %v, want a GasPriceError", i, err)
+		}
+	}
+
+	if e, _ = newEngine(t, Policy{MaxGasPrice: "0.5gwei"}); e.Check(chainID, legacy(gwei(1))) == nil {
+		t.Fatal("gas price above 0.5 gwei accepted")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	datadir := t.TempDir()
+	if e, err := Load(datadir, account); e != nil || err != nil {
+		t.Fatalf("Load without a policy file = %v, %v, want nil", e, err)
+	}
+	if err := os.MkdirAll(filepath.Join(datadir, Dir), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(File(datadir, account), []byte(`{"eth":{"daily":"1ether"},"unknown":1}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(datadir, account); err == nil {
+		t.Fatal("policy with an unknown field accepted")
+	}
+	if err := os.WriteFile(File(datadir, account), []byte(`{"eth":{"daily":"1ether"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	e, err := Load(datadir, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sign(e, etherTx(0, recipient, ether(1))); err != nil {
+		t.Fatal(err)
+	}
+
+	// 支出记录保存在文件中，其他进程加载的策略也会计入。
+	other, err := Load(datadir, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Check(chainID, etherTx(1, recipient, big.NewInt(1))); !errors.Is(err, ErrDailyLimit) {
+		t.Fatalf("Check returned %v, want ErrDailyLimit", err)
+	}
+}
+
+func TestLockSerializesSigners(t *testing.T) {
+	datadir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(datadir, Dir), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(File(datadir, account), []byte(`{"eth":{"daily":"1ether"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// 每个签名者单独加载策略，相当于不同的进程；限额只够其中一笔交易。
+	const signers = 8
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		signed int
+	)
+	for i := 0; i < signers; i++ {
+		e, err := Load(datadir, account)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func(nonce uint64) {
+			defer wg.Done()
+			unlock, err := e.Lock()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer unlock()
+			tx := etherTx(nonce, recipient, ether(1))
+			if err := e.Check(chainID, tx); err != nil {
+				if !errors.Is(err, ErrDailyLimit) {
+					t.Error(err)
+				}
+				return
+			}
+			// 检查和记录之间留出时间，没有锁时其他签名者会在这里通过检查。
+			time.Sleep(5 * time.Millisecond)
+			if err := e.Record(chainID, tx); err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			signed++
+			mu.Unlock()
+		}(uint64(i))
+	}
+	wg.Wait()
+	if signed != 1 {
+		t.Fatalf("%d transactions passed the daily limit, want 1", signed)
+	}
+	if _, err := os.Stat(lockFile(datadir, account)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("lock file left behind: %v", err)
+	}
+}
+
+func TestLockTimeout(t *testing.T) {
+	datadir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(datadir, Dir), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(File(datadir, account), []byte(`{}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	e, err := Load(datadir, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 超过 staleLock 的锁文件视为遗留的锁。
+	file := lockFile(datadir, account)
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * staleLock)
+	if err := os.Chtimes(file, old, old); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err := e.Lock()
+	if err != nil {
+		t.Fatalf("stale lock not removed: %v", err)
+	}
+	unlock()
+}
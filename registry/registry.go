@@ -0,0 +1,118 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"go_wallet/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// FileName 是代币注册表在数据目录下的文件名。
+const FileName = "tokens.json"
+
+// Token 是注册表中记录的 ERC20 代币信息。
+type Token struct {
+	Address  common.Address `json:"address"`
+	Symbol   string         `json:"symbol"`
+	Name     string         `json:"name"`
+	Decimals uint8          `json:"decimals"`
+}
+
+// Registry 是按链 ID 分组的本地代币注册表。
+type Registry struct {
+	file   string
+	Chains map[string][]Token `json:"chains"`
+}
+
+// Load 从文件中加载代币注册表，文件不存在时返回空的注册表。
+func Load(file string) (*Registry, error) {
+	r := &Registry{file: file, Chains: map[string][]Token{}}
+	content, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, r); err != nil {
+		return nil, fmt.Errorf("invalid token registry %s: %w", file, err)
+	}
+	if r.Chains == nil {
+		r.Chains = map[string][]Token{}
+	}
+	return r, nil
+}
+
+// Save 将代币注册表写回文件。
+func (r *Registry) Save() error {
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteKeyFile(r.file, content)
+}
+
+// List 返回指定链上的所有代币，按符号排序。
+func (r *Registry) List(chainID *big.Int) []Token {
+	tokens := append([]Token(nil), r.Chains[chainID.String()]...)
+	sort.Slice(tokens, func(i, j int) bool {
+		return strings.ToLower(tokens[i].Symbol) < strings.ToLower(tokens[j].Symbol)
+	})
+	return tokens
+}
+
+// Add 将代币加入指定链的注册表。地址已存在时更新代币信息；
+// 符号与同链上的其他代币重复时返回错误，避免按符号查找时产生歧义。
+func (r *Registry) Add(chainID *big.Int, token Token) error {
+	key := chainID.String()
+	tokens := r.Chains[key]
+	for i, t := range tokens {
+		if t.Address == token.Address {
+			tokens[i] = token
+			return nil
+		}
+		if token.Symbol != "" && strings.EqualFold(t.Symbol, token.Symbol) {
+			return fmt.Errorf("symbol %s is already used by %s on chain %s", t.Symbol, t.Address.Hex(), key)
+		}
+	}
+	r.Chains[key] = append(tokens, token)
+	return nil
+}
+
+// Remove 按符号或地址从指定链的注册表中删除代币，返回被删除的代币。
+func (r *Registry) Remove(chainID *big.Int, symbolOrAddress string) (Token, error) {
+	key := chainID.String()
+	tokens := r.Chains[key]
+	for i, t := range tokens {
+		if t.matches(symbolOrAddress) {
+			r.Chains[key] = append(tokens[:i], tokens[i+1:]...)
+			return t, nil
+		}
+	}
+	return Token{}, fmt.Errorf("token %s not found on chain %s", symbolOrAddress, key)
+}
+
+// Find 按符号（不区分大小写）或地址查找指定链上的代币。
+func (r *Registry) Find(chainID *big.Int, symbolOrAddress string) (Token, bool) {
+	for _, t := range r.Chains[chainID.String()] {
+		if t.matches(symbolOrAddress) {
+			return t, true
+		}
+	}
+	return Token{}, false
+}
+
+// matches 判断代币的地址或符号是否与给定字符串匹配。
+func (t Token) matches(symbolOrAddress string) bool {
+	if common.IsHexAddress(symbolOrAddress) {
+		return t.Address == common.HexToAddress(symbolOrAddress)
+	}
+	return strings.EqualFold(t.Symbol, symbolOrAddress)
+}
@@ -0,0 +1,348 @@
+package signatures
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go_wallet/utils"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// FileName 是签名数据库在数据目录下的文件名。
+const FileName = "signatures.json"
+
+var (
+	identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+	intAlias   = regexp.MustCompile(`^(u?int)(\[.*)?$`) // uint、int 是 uint256、int256 的别名
+)
+
+// DB 是本地的签名数据库，将 4 字节选择器和事件主题映射到文本签名，用于在没有 ABI 时解析调用数据和日志。
+// 签名保留参数名和 indexed 标记，例如 Transfer(address indexed from,address indexed to,uint256 value)。
+type DB struct {
+	file      string
+	Functions map[string][]string `json:"functions"` // 选择器 -> 函数或自定义错误的签名
+	Events    map[string][]string `json:"events"`    // 主题 -> 事件签名
+}
+
+// Load 从文件中加载签名数据库，文件不存在时返回空的数据库。
+func Load(file string) (*DB, error) {
+	db := &DB{file: file}
+	content, err := os.ReadFile(file)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(content, db); err != nil {
+			return nil, fmt.Errorf("invalid signature database %s: %w", file, err)
+		}
+	}
+	if db.Functions == nil {
+		db.Functions = map[string][]string{}
+	}
+	if db.Events == nil {
+		db.Events = map[string][]string{}
+	}
+	return db, nil
+}
+
+// Save 将签名数据库写回文件。
+func (db *DB) Save() error {
+	content, err := json.MarshalIndent(db, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteKeyFile(db.file, content)
+}
+
+// AddFunction 将函数或自定义错误的签名加入数据库。
+// 参数:
+//
+//	sig - 文本签名，例如 transfer(address to,uint256 amount)。
+//
+// 返回值:
+//
+//	bool - 是否新加入；规范签名相同的记录已存在时返回 false。
+//	error - 如果签名格式错误，则返回错误信息。
+func (db *DB) AddFunction(sig string) (bool, error) {
+	m, err := ParseMethod(sig)
+	if err != nil {
+		return false, err
+	}
+	return add(db.Functions, hexutil.Encode(m.ID), m.Sig, FormatMethod(m.RawName, m.Inputs)), nil
+}
+
+// AddEvent 将事件签名加入数据库，返回值的含义与 AddFunction 相同。
+func (db *DB) AddEvent(sig string) (bool, error) {
+	e, err := ParseEvent(sig)
+	if err != nil {
+		return false, err
+	}
+	return add(db.Events, e.ID.Hex(), e.Sig, FormatEvent(e)), nil
+}
+
+// AddABI 将 ABI 中的所有方法、自定义错误和非匿名事件加入数据库，返回新加入的签名数量。
+func (db *DB) AddABI(parsed *abi.ABI) int {
+	n := 0
+	for _, m := range parsed.Methods {
+		if add(db.Functions, hexutil.Encode(m.ID), m.Sig, FormatMethod(m.RawName, m.Inputs)) {
+			n++
+		}
+	}
+	for _, e := range parsed.Errors {
+		if add(db.Functions, hexutil.Encode(e.ID[:4]), e.Sig, FormatMethod(e.Name, e.Inputs)) {
+			n++
+		}
+	}
+	for _, e := range parsed.Events {
+		if !e.Anonymous && add(db.Events, e.ID.Hex(), e.Sig, FormatEvent(e)) {
+			n++
+		}
+	}
+	return n
+}
+
+// LookupFunction 返回选择器对应的所有函数签名；选择器冲突时可能有多个。
+func (db *DB) LookupFunction(selector []byte) []abi.Method {
+	var methods []abi.Method
+	for _, sig := range db.Functions[hexutil.Encode(selector)] {
+		if m, err := ParseMethod(sig); err == nil {
+			methods = append(methods, m)
+		}
+	}
+	return methods
+}
+
+// LookupEvent 返回主题对应的所有事件签名。
+func (db *DB) LookupEvent(topic common.Hash) []abi.Event {
+	var events []abi.Event
+	for _, sig := range db.Events[topic.Hex()] {
+		if e, err := ParseEvent(sig); err == nil {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// add 将签名加入 key 对应的列表，规范签名已存在时不重复加入。
+func add(table map[string][]string, key, canonical, sig string) bool {
+	for _, existing := range table[key] {
+		if name, args, err := parseSignature(existing); err == nil && canonicalSig(name, args) == canonical {
+			return false
+		}
+	}
+	table[key] = append(table[key], sig)
+	sort.Strings(table[key])
+	return true
+}
+
+// ParseMethod 将文本签名解析为方法，参数类型支持 tuple，例如 f((address,uint256)[] calls)。
+func ParseMethod(sig string) (abi.Method, error) {
+	name, args, err := parseSignature(sig)
+	if err != nil {
+		return abi.Method{}, err
+	}
+	for _, arg := range args {
+		if arg.Indexed {
+			return abi.Method{}, fmt.Errorf("invalid function signature %q: indexed is only allowed in events", sig)
+		}
+	}
+	return abi.NewMethod(name, name, abi.Function, "", false, false, args, nil), nil
+}
+
+// ParseEvent 将文本签名解析为事件，indexed 参数需要标明，例如 Transfer(address indexed from,address indexed to,uint256 value)。
+func ParseEvent(sig string) (abi.Event, error) {
+	name, args, err := parseSignature(sig)
+	if err != nil {
+		return abi.Event{}, err
+	}
+	return abi.NewEvent(name, name, false, args), nil
+}
+
+// FormatMethod 生成带参数名的函数签名文本。
+func FormatMethod(name string, inputs abi.Arguments) string {
+	params := make([]string, len(inputs))
+	for i, in := range inputs {
+		params[i] = formatParam(in)
+	}
+	return name + "(" + strings.Join(params, ",") + ")"
+}
+
+// FormatEvent 生成带参数名和 indexed 标记的事件签名文本。
+func FormatEvent(e abi.Event) string {
+	return FormatMethod(e.RawName, e.Inputs)
+}
+
+// formatParam 生成单个参数的文本：类型 [indexed] [参数名]。
+func formatParam(arg abi.Argument) string {
+	s := formatType(arg.Type)
+	if arg.Indexed {
+		s += " indexed"
+	}
+	if arg.Name != "" {
+		s += " " + arg.Name
+	}
+	return s
+}
+
+// formatType 生成类型文本，与 Type.String 不同的是 tuple 保留成员名。
+func formatType(t abi.Type) string {
+	switch t.T {
+	case abi.SliceTy:
+		return formatType(*t.Elem) + "[]"
+	case abi.ArrayTy:
+		return fmt.Sprintf("%s[%d]", formatType(*t.Elem), t.Size)
+	case abi.TupleTy:
+		comps := make([]string, len(t.TupleElems))
+		for i, elem := range t.TupleElems {
+			comps[i] = formatType(*elem) + " " + t.TupleRawNames[i]
+		}
+		return "(" + strings.Join(comps, ",") + ")"
+	}
+	return t.String()
+}
+
+// canonicalSig 生成计算选择器使用的规范签名，只包含类型。
+func canonicalSig(name string, args abi.Arguments) string {
+	types := make([]string, len(args))
+	for i, arg := range args {
+		types[i] = arg.Type.String()
+	}
+	return name + "(" + strings.Join(types, ",") + ")"
+}
+
+// parseSignature 解析 name(type [indexed] [name],...) 形式的签名。
+func parseSignature(sig string) (string, abi.Arguments, error) {
+	sig = strings.TrimSpace(sig)
+	open := strings.Index(sig, "(")
+	if open <= 0 || !strings.HasSuffix(sig, ")") {
+		return "", nil, fmt.Errorf("invalid signature %q, expected name(type,...)", sig)
+	}
+	name := strings.TrimSpace(sig[:open])
+	if !identifier.MatchString(name) {
+		return "", nil, fmt.Errorf("invalid name %q in signature %q", name, sig)
+	}
+	params, err := splitParams(sig[open+1 : len(sig)-1])
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid signature %q: %w", sig, err)
+	}
+	args := make(abi.Arguments, len(params))
+	for i, p := range params {
+		m, err := parseParam(p)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid signature %q: %w", sig, err)
+		}
+		typ, err := abi.NewType(m.Type, "", m.Components)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid signature %q: %w", sig, err)
+		}
+		args[i] = abi.Argument{Name: m.Name, Type: typ, Indexed: m.Indexed}
+	}
+	return name, args, nil
+}
+
+// splitParams 按顶层的逗号拆分参数列表，tuple 内部的逗号不拆分。
+func splitParams(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var (
+		params []string
+		depth  int
+		start  int
+	)
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, errors.New("unbalanced parentheses")
+			}
+		case ',':
+			if depth == 0 {
+				params = append(params, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, errors.New("unbalanced parentheses")
+	}
+	params = append(params, strings.TrimSpace(s[start:]))
+	for _, p := range params {
+		if p == "" {
+			return nil, errors.New("empty parameter")
+		}
+	}
+	return params, nil
+}
+
+// parseParam 解析单个参数：类型 [indexed] [参数名]，类型可以是 tuple，例如 (address,uint256)[] calls。
+func parseParam(p string) (abi.ArgumentMarshaling, error) {
+	var typ, rest string
+	if strings.HasPrefix(p, "(") {
+		end := strings.LastIndex(p, ")") + 1
+		for end < len(p) && p[end] != ' ' {
+			end++
+		}
+		typ, rest = p[:end], p[end:]
+	} else {
+		typ, rest, _ = strings.Cut(p, " ")
+	}
+	m, err := parseType(typ)
+	if err != nil {
+		return abi.ArgumentMarshaling{}, err
+	}
+	fields := strings.Fields(rest)
+	if len(fields) > 0 && fields[0] == "indexed" {
+		m.Indexed = true
+		fields = fields[1:]
+	}
+	switch len(fields) {
+	case 0:
+	case 1:
+		if !identifier.MatchString(fields[0]) {
+			return abi.ArgumentMarshaling{}, fmt.Errorf("invalid parameter name %q", fields[0])
+		}
+		m.Name = fields[0]
+	default:
+		return abi.ArgumentMarshaling{}, fmt.Errorf("invalid parameter %q", p)
+	}
+	return m, nil
+}
+
+// parseType 将类型文本转换为 ABI 类型描述，tuple 的成员没有名字时依次命名为 f0、f1……
+func parseType(typ string) (abi.ArgumentMarshaling, error) {
+	if !strings.HasPrefix(typ, "(") {
+		return abi.ArgumentMarshaling{Type: intAlias.ReplaceAllString(typ, "${1}256${2}")}, nil
+	}
+	end := strings.LastIndex(typ, ")")
+	parts, err := splitParams(typ[1:end])
+	if err != nil {
+		return abi.ArgumentMarshaling{}, err
+	}
+	m := abi.ArgumentMarshaling{Type: "tuple" + typ[end+1:]}
+	for i, part := range parts {
+		c, err := parseParam(part)
+		if err != nil {
+			return abi.ArgumentMarshaling{}, err
+		}
+		if c.Indexed {
+			return abi.ArgumentMarshaling{}, fmt.Errorf("invalid tuple component %q", part)
+		}
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("f%d", i)
+		}
+		m.Components = append(m.Components, c)
+	}
+	return m, nil
+}
@@ -0,0 +1,15 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.15;
+
+import "./IERC20.sol";
+
+interface IERC20Metadata is IERC20 {
+    // 代币名称
+    function name() external view returns (string memory);
+
+    // 代币符号
+    function symbol() external view returns (string memory);
+
+    // 代币精度
+    function decimals() external view returns (uint8);
+}
@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.15;
+
+// 最小的 ENS 注册表和解析器实现，只包含钱包用到的接口和设置记录所需的方法，
+// 用于在本地测试链上部署 ENS。
+
+contract ENSRegistry {
+    struct Record {
+        address owner;
+        address resolver;
+    }
+
+    mapping(bytes32 => Record) private records;
+
+    constructor() {
+        records[bytes32(0)].owner = msg.sender;
+    }
+
+    modifier authorised(bytes32 node) {
+        require(records[node].owner == msg.sender);
+        _;
+    }
+
+    function owner(bytes32 node) public view returns (address) {
+        return records[node].owner;
+    }
+
+    function resolver(bytes32 node) public view returns (address) {
+        return records[node].resolver;
+    }
+
+    function setOwner(bytes32 node, address owner_) public authorised(node) {
+        records[node].owner = owner_;
+    }
+
+    function setSubnodeOwner(
+        bytes32 node,
+        bytes32 label,
+        address owner_
+    ) public authorised(node) returns (bytes32) {
+        bytes32 subnode = keccak256(abi.encodePacked(node, label));
+        records[subnode].owner = owner_;
+        return subnode;
+    }
+
+    function setResolver(bytes32 node, address resolver_) public authorised(node) {
+        records[node].resolver = resolver_;
+    }
+}
+
+contract ENSResolver {
+    ENSRegistry private ens;
+
+    mapping(bytes32 => address) private addrs;
+    mapping(bytes32 => string) private names;
+
+    constructor(ENSRegistry ens_) {
+        ens = ens_;
+    }
+
+    modifier authorised(bytes32 node) {
+        require(ens.owner(node) == msg.sender);
+        _;
+    }
+
+    function addr(bytes32 node) public view returns (address) {
+        return addrs[node];
+    }
+
+    function name(bytes32 node) public view returns (string memory) {
+        return names[node];
+    }
+
+    function setAddr(bytes32 node, address addr_) public authorised(node) {
+        addrs[node] = addr_;
+    }
+
+    function setName(bytes32 node, string calldata name_) public authorised(node) {
+        names[node] = name_;
+    }
+}
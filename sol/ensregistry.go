@@ -0,0 +1,328 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package sol
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+	_ = abi.ConvertType
+)
+
+// ENSRegistryMetaData contains all meta data concerning the ENSRegistry contract.
+var ENSRegistryMetaData = &bind.MetaData{
+	ABI: "[{\"inputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"node\",\"type\":\"bytes32\"}],\"name\":\"owner\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"node\",\"type\":\"bytes32\"}],\"name\":\"resolver\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"node\",\"type\":\"bytes32\"},{\"internalType\":\"address\",\"name\":\"owner_\",\"type\":\"address\"}],\"name\":\"setOwner\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"node\",\"type\":\"bytes32\"},{\"internalType\":\"address\",\"name\":\"resolver_\",\"type\":\"address\"}],\"name\":\"setResolver\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"node\",\"type\":\"bytes32\"},{\"internalType\":\"bytes32\",\"name\":\"label\",\"type\":\"bytes32\"},{\"internalType\":\"address\",\"name\":\"owner_\",\"type\":\"address\"}],\"name\":\"setSubnodeOwner\",\"outputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]",
+	Bin: "0x608060405234801561001057600080fd5b5060008080526020527fad3228b676f7d3cd4284a5443f17f1962b36e491b30a40b2405849e597ba5fb580546001600160a01b03191633179055610313806100596000396000f3fe608060405234801561001057600080fd5b50600436106100575760003560e01c80630178b8bf1461005c57806302571be3146100a557806306ab5923146100ce5780631896f70a146100ef5780635b0fc9c314610104575b600080fd5b61008861006a366004610247565b6000908152602081905260409020600101546001600160a01b031690565b6040516001600160a01b0390911681526020015b60405180910390f35b6100886100b3366004610247565b6000908152602081905260409020546001600160a01b031690565b6100e16100dc36600461027c565b610117565b60405190815260200161009c565b6101026100fd3660046102b1565b61019c565b005b6101026101123660046102b1565b6101f3565b60008381526020819052604081205484906001600160a01b0316331461013c57600080fd5b604080516020810187905290810185905260009060600160408051601f198184030181529181528151602092830120600081815292839052912080546001600160a01b0387166001600160a01b0319909116179055925050509392505050565b60008281526020819052604090205482906001600160a01b031633146101c157600080fd5b5060009182526020829052604090912060010180546001600160a01b0319166001600160a01b03909216919091179055565b60008281526020819052604090205482906001600160a01b0316331461021857600080fd5b5060009182526020829052604090912080546001600160a01b0319166001600160a01b03909216919091179055565b60006020828403121561025957600080fd5b5035919050565b80356001600160a01b038116811461027757600080fd5b919050565b60008060006060848603121561029157600080fd5b83359250602084013591506102a860408501610260565b90509250925092565b600080604083850312156102c457600080fd5b823591506102d460208401610260565b9050925092905056fea26469706673582212201a9ccd51e0d986ec8c6405ae770c83e577d0decde37a4f7190f8ed5f639fa65c64736f6c63430008150033",
+}
+
+// ENSRegistryABI is the input ABI used to generate the binding from.
+// Deprecated: Use ENSRegistryMetaData.ABI instead.
+var ENSRegistryABI = ENSRegistryMetaData.ABI
+
+// ENSRegistryBin is the compiled bytecode used for deploying new contracts.
+// Deprecated: Use ENSRegistryMetaData.Bin instead.
+var ENSRegistryBin = ENSRegistryMetaData.Bin
+
+// DeployENSRegistry deploys a new Ethereum contract, binding an instance of ENSRegistry to it.
+func DeployENSRegistry(auth *bind.TransactOpts, backend bind.ContractBackend) (common.Address, *types.Transaction, *ENSRegistry, error) {
+	parsed, err := ENSRegistryMetaData.GetAbi()
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	if parsed == nil {
+		return common.Address{}, nil, nil, errors.New("GetABI returned nil")
+	}
+
+	address, tx, contract, err := bind.DeployContract(auth, *parsed, common.FromHex(ENSRegistryBin), backend)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	return address, tx, &ENSRegistry{ENSRegistryCaller: ENSRegistryCaller{contract: contract}, ENSRegistryTransactor: ENSRegistryTransactor{contract: contract}, ENSRegistryFilterer: ENSRegistryFilterer{contract: contract}}, nil
+}
+
+// ENSRegistry is an auto generated Go binding around an Ethereum contract.
+type ENSRegistry struct {
+	ENSRegistryCaller     // Read-only binding to the contract
+	ENSRegistryTransactor // Write-only binding to the contract
+	ENSRegistryFilterer   // Log filterer for contract events
+}
+
+// ENSRegistryCaller is an auto generated read-only Go binding around an Ethereum contract.
+type ENSRegistryCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ENSRegistryTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type ENSRegistryTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ENSRegistryFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type ENSRegistryFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ENSRegistrySession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type ENSRegistrySession struct {
+	Contract     *ENSRegistry      // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// ENSRegistryCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type ENSRegistryCallerSession struct {
+	Contract *ENSRegistryCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts      // Call options to use throughout this session
+}
+
+// ENSRegistryTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type ENSRegistryTransactorSession struct {
+	Contract     *ENSRegistryTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts      // Transaction auth options to use throughout this session
+}
+
+// ENSRegistryRaw is an auto generated low-level Go binding around an Ethereum contract.
+type ENSRegistryRaw struct {
+	Contract *ENSRegistry // Generic contract binding to access the raw methods on
+}
+
+// ENSRegistryCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type ENSRegistryCallerRaw struct {
+	Contract *ENSRegistryCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// ENSRegistryTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type ENSRegistryTransactorRaw struct {
+	Contract *ENSRegistryTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewENSRegistry creates a new instance of ENSRegistry, bound to a specific deployed contract.
+func NewENSRegistry(address common.Address, backend bind.ContractBackend) (*ENSRegistry, error) {
+	contract, err := bindENSRegistry(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &ENSRegistry{ENSRegistryCaller: ENSRegistryCaller{contract: contract}, ENSRegistryTransactor: ENSRegistryTransactor{contract: contract}, ENSRegistryFilterer: ENSRegistryFilterer{contract: contract}}, nil
+}
+
+// NewENSRegistryCaller creates a new read-only instance of ENSRegistry, bound to a specific deployed contract.
+func NewENSRegistryCaller(address common.Address, caller bind.ContractCaller) (*ENSRegistryCaller, error) {
+	contract, err := bindENSRegistry(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ENSRegistryCaller{contract: contract}, nil
+}
+
+// NewENSRegistryTransactor creates a new write-only instance of ENSRegistry, bound to a specific deployed contract.
+func NewENSRegistryTransactor(address common.Address, transactor bind.ContractTransactor) (*ENSRegistryTransactor, error) {
+	contract, err := bindENSRegistry(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ENSRegistryTransactor{contract: contract}, nil
+}
+
+// NewENSRegistryFilterer creates a new log filterer instance of ENSRegistry, bound to a specific deployed contract.
+func NewENSRegistryFilterer(address common.Address, filterer bind.ContractFilterer) (*ENSRegistryFilterer, error) {
+	contract, err := bindENSRegistry(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &ENSRegistryFilterer{contract: contract}, nil
+}
+
+// bindENSRegistry binds a generic wrapper to an already deployed contract.
+func bindENSRegistry(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := ENSRegistryMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, *parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_ENSRegistry *ENSRegistryRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _ENSRegistry.Contract.ENSRegistryCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_ENSRegistry *ENSRegistryRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _ENSRegistry.Contract.ENSRegistryTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_ENSRegistry *ENSRegistryRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _ENSRegistry.Contract.ENSRegistryTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_ENSRegistry *ENSRegistryCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _ENSRegistry.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_ENSRegistry *ENSRegistryTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _ENSRegistry.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_ENSRegistry *ENSRegistryTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _ENSRegistry.Contract.contract.Transact(opts, method, params...)
+}
+
+// Owner is a free data retrieval call binding the contract method 0x02571be3.
+//
+// Solidity: function owner(bytes32 node) view returns(address)
+func (_ENSRegistry *ENSRegistryCaller) Owner(opts *bind.CallOpts, node [32]byte) (common.Address, error) {
+	var out []interface{}
+	err := _ENSRegistry.contract.Call(opts, &out, "owner", node)
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// Owner is a free data retrieval call binding the contract method 0x02571be3.
+//
+// Solidity: function owner(bytes32 node) view returns(address)
+func (_ENSRegistry *ENSRegistrySession) Owner(node [32]byte) (common.Address, error) {
+	return _ENSRegistry.Contract.Owner(&_ENSRegistry.CallOpts, node)
+}
+
+// Owner is a free data retrieval call binding the contract method 0x02571be3.
+//
+// Solidity: function owner(bytes32 node) view returns(address)
+func (_ENSRegistry *ENSRegistryCallerSession) Owner(node [32]byte) (common.Address, error) {
+	return _ENSRegistry.Contract.Owner(&_ENSRegistry.CallOpts, node)
+}
+
+// Resolver is a free data retrieval call binding the contract method 0x0178b8bf.
+//
+// Solidity: function resolver(bytes32 node) view returns(address)
+func (_ENSRegistry *ENSRegistryCaller) Resolver(opts *bind.CallOpts, node [32]byte) (common.Address, error) {
+	var out []interface{}
+	err := _ENSRegistry.contract.Call(opts, &out, "resolver", node)
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// Resolver is a free data retrieval call binding the contract method 0x0178b8bf.
+//
+// Solidity: function resolver(bytes32 node) view returns(address)
+func (_ENSRegistry *ENSRegistrySession) Resolver(node [32]byte) (common.Address, error) {
+	return _ENSRegistry.Contract.Resolver(&_ENSRegistry.CallOpts, node)
+}
+
+// Resolver is a free data retrieval call binding the contract method 0x0178b8bf.
+//
+// Solidity: function resolver(bytes32 node) view returns(address)
+func (_ENSRegistry *ENSRegistryCallerSession) Resolver(node [32]byte) (common.Address, error) {
+	return _ENSRegistry.Contract.Resolver(&_ENSRegistry.CallOpts, node)
+}
+
+// SetOwner is a paid mutator transaction binding the contract method 0x5b0fc9c3.
+//
+// Solidity: function setOwner(bytes32 node, address owner_) returns()
+func (_ENSRegistry *ENSRegistryTransactor) SetOwner(opts *bind.TransactOpts, node [32]byte, owner_ common.Address) (*types.Transaction, error) {
+	return _ENSRegistry.contract.Transact(opts, "setOwner", node, owner_)
+}
+
+// SetOwner is a paid mutator transaction binding the contract method 0x5b0fc9c3.
+//
+// Solidity: function setOwner(bytes32 node, address owner_) returns()
+func (_ENSRegistry *ENSRegistrySession) SetOwner(node [32]byte, owner_ common.Address) (*types.Transaction, error) {
+	return _ENSRegistry.Contract.SetOwner(&_ENSRegistry.TransactOpts, node, owner_)
+}
+
+// SetOwner is a paid mutator transaction binding the contract method 0x5b0fc9c3.
+//
+// Solidity: function setOwner(bytes32 node, address owner_) returns()
+func (_ENSRegistry *ENSRegistryTransactorSession) SetOwner(node [32]byte, owner_ common.Address) (*types.Transaction, error) {
+	return _ENSRegistry.Contract.SetOwner(&_ENSRegistry.TransactOpts, node, owner_)
+}
+
+// SetResolver is a paid mutator transaction binding the contract method 0x1896f70a.
+//
+// Solidity: function setResolver(bytes32 node, address resolver_) returns()
+func (_ENSRegistry *ENSRegistryTransactor) SetResolver(opts *bind.TransactOpts, node [32]byte, resolver_ common.Address) (*types.Transaction, error) {
+	return _ENSRegistry.contract.Transact(opts, "setResolver", node, resolver_)
+}
+
+// SetResolver is a paid mutator transaction binding the contract method 0x1896f70a.
+//
+// Solidity: function setResolver(bytes32 node, address resolver_) returns()
+func (_ENSRegistry *ENSRegistrySession) SetResolver(node [32]byte, resolver_ common.Address) (*types.Transaction, error) {
+	return _ENSRegistry.Contract.SetResolver(&_ENSRegistry.TransactOpts, node, resolver_)
+}
+
+// SetResolver is a paid mutator transaction binding the contract method 0x1896f70a.
+//
+// Solidity: function setResolver(bytes32 node, address resolver_) returns()
+func (_ENSRegistry *ENSRegistryTransactorSession) SetResolver(node [32]byte, resolver_ common.Address) (*types.Transaction, error) {
+	return _ENSRegistry.Contract.SetResolver(&_ENSRegistry.TransactOpts, node, resolver_)
+}
+
+// SetSubnodeOwner is a paid mutator transaction binding the contract method 0x06ab5923.
+//
+// Solidity: function setSubnodeOwner(bytes32 node, bytes32 label, address owner_) returns(bytes32)
+func (_ENSRegistry *ENSRegistryTransactor) SetSubnodeOwner(opts *bind.TransactOpts, node [32]byte, label [32]byte, owner_ common.Address) (*types.Transaction, error) {
+	return _ENSRegistry.contract.Transact(opts, "setSubnodeOwner", node, label, owner_)
+}
+
+// SetSubnodeOwner is a paid mutator transaction binding the contract method 0x06ab5923.
+//
+// Solidity: function setSubnodeOwner(bytes32 node, bytes32 label, address owner_) returns(bytes32)
+func (_ENSRegistry *ENSRegistrySession) SetSubnodeOwner(node [32]byte, label [32]byte, owner_ common.Address) (*types.Transaction, error) {
+	return _ENSRegistry.Contract.SetSubnodeOwner(&_ENSRegistry.TransactOpts, node, label, owner_)
+}
+
+// SetSubnodeOwner is a paid mutator transaction binding the contract method 0x06ab5923.
+//
+// Solidity: function setSubnodeOwner(bytes32 node, bytes32 label, address owner_) returns(bytes32)
+func (_ENSRegistry *ENSRegistryTransactorSession) SetSubnodeOwner(node [32]byte, label [32]byte, owner_ common.Address) (*types.Transaction, error) {
+	return _ENSRegistry.Contract.SetSubnodeOwner(&_ENSRegistry.TransactOpts, node, label, owner_)
+}
@@ -0,0 +1,307 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package sol
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+	_ = abi.ConvertType
+)
+
+// ENSResolverMetaData contains all meta data concerning the ENSResolver contract.
+var ENSResolverMetaData = &bind.MetaData{
+	ABI: "[{\"inputs\":[{\"internalType\":\"contractENSRegistry\",\"name\":\"ens_\",\"type\":\"address\"}],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"node\",\"type\":\"bytes32\"}],\"name\":\"addr\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"node\",\"type\":\"bytes32\"}],\"name\":\"name\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"node\",\"type\":\"bytes32\"},{\"internalType\":\"address\",\"name\":\"addr_\",\"type\":\"address\"}],\"name\":\"setAddr\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"node\",\"type\":\"bytes32\"},{\"internalType\":\"string\",\"name\":\"name_\",\"type\":\"string\"}],\"name\":\"setName\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]",
+	Bin: "0x608060405234801561001057600080fd5b5060405161064f38038061064f83398101604081905261002f91610054565b600080546001600160a01b0319166001600160a01b0392909216919091179055610084565b60006020828403121561006657600080fd5b81516001600160a01b038116811461007d57600080fd5b9392505050565b6105bc806100936000396000f3fe608060405234801561001057600080fd5b506004361061004c5760003560e01c80633b3b57de14610051578063691f34311461009757806377372213146100b7578063d5fa2b00146100cc575b600080fd5b61007a61005f3660046102d8565b6000908152600160205260409020546001600160a01b031690565b6040516001600160a01b0390911681526020015b60405180910390f35b6100aa6100a53660046102d8565b6100df565b60405161008e91906102f1565b6100ca6100c536600461033f565b610181565b005b6100ca6100da3660046103d3565b610225565b60008181526002602052604090208054606091906100fc90610403565b80601f016020809104026020016040519081016040528092919081815260200182805461012890610403565b80156101755780601f1061014a57610100808354040283529160200191610175565b820191906000526020600020905b81548152906001019060200180831161015857829003601f168201915b50505050509050919050565b6000546040516302571be360e01b815260048101859052849133916001600160a01b03909116906302571be390602401602060405180830381865afa1580156101ce573d6000803e3d6000fd5b505050506040513d601f19601f820116820180604052508101906101f2919061043d565b6001600160a01b03161461020557600080fd5b600084815260026020526040902061021e8385836104c6565b5050505050565b6000546040516302571be360e01b815260048101849052839133916001600160a01b03909116906302571be390602401602060405180830381865afa158015610272573d6000803e3d6000fd5b505050506040513d601f19601f82011682018060405250810190610296919061043d565b6001600160a01b0316146102a957600080fd5b5060009182526001602052604090912080546001600160a01b0319166001600160a01b03909216919091179055565b6000602082840312156102ea57600080fd5b5035919050565b600060208083528351808285015260005b8181101561031e57858101830151858201604001528201610302565b506000604082860101526040601f19601f8301168501019250505092915050565b60008060006040848603121561035457600080fd5b83359250602084013567ffffffffffffffff8082111561037357600080fd5b818601915086601f83011261038757600080fd5b81358181111561039657600080fd5b8760208285010111156103a857600080fd5b6020830194508093505050509250925092565b6001600160a01b03811681146103d057600080fd5b50565b600080604083850312156103e657600080fd5b8235915060208301356103f8816103bb565b809150509250929050565b600181811c9082168061041757607f821691505b60208210810361043757634e487b7160e01b600052602260045260246000fd5b50919050565b60006020828403121561044f57600080fd5b815161045a816103bb565b9392505050565b634e487b7160e01b600052604160045260246000fd5b601f8211156104c157600081815260208120601f850160051c8101602086101561049e5750805b601f850160051c820191505b818110156104bd578281556001016104aa565b5050505b505050565b67ffffffffffffffff8311156104de576104de610461565b6104f2836104ec8354610403565b83610477565b6000601f841160018114610526576000851561050e5750838201355b600019600387901b1c1916600186901b17835561021e565b600083815260209020601f19861690835b828110156105575786850135825560209485019460019092019101610537565b50868210156105745760001960f88860031b161c19848701351681555b505060018560011b018355505050505056fea26469706673582212209ec16fa10ac029e54043c0a1f5925404bf4535e7472a53a35e8663affd5879f264736f6c63430008150033",
+}
+
+// ENSResolverABI is the input ABI used to generate the binding from.
+// Deprecated: Use ENSResolverMetaData.ABI instead.
+var ENSResolverABI = ENSResolverMetaData.ABI
+
+// ENSResolverBin is the compiled bytecode used for deploying new contracts.
+// Deprecated: Use ENSResolverMetaData.Bin instead.
+var ENSResolverBin = ENSResolverMetaData.Bin
+
+// DeployENSResolver deploys a new Ethereum contract, binding an instance of ENSResolver to it.
+func DeployENSResolver(auth *bind.TransactOpts, backend bind.ContractBackend, ens_ common.Address) (common.Address, *types.Transaction, *ENSResolver, error) {
+	parsed, err := ENSResolverMetaData.GetAbi()
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	if parsed == nil {
+		return common.Address{}, nil, nil, errors.New("GetABI returned nil")
+	}
+
+	address, tx, contract, err := bind.DeployContract(auth, *parsed, common.FromHex(ENSResolverBin), backend, ens_)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	return address, tx, &ENSResolver{ENSResolverCaller: ENSResolverCaller{contract: contract}, ENSResolverTransactor: ENSResolverTransactor{contract: contract}, ENSResolverFilterer: ENSResolverFilterer{contract: contract}}, nil
+}
+
+// ENSResolver is an auto generated Go binding around an Ethereum contract.
+type ENSResolver struct {
+	ENSResolverCaller     // Read-only binding to the contract
+	ENSResolverTransactor // Write-only binding to the contract
+	ENSResolverFilterer   // Log filterer for contract events
+}
+
+// ENSResolverCaller is an auto generated read-only Go binding around an Ethereum contract.
+type ENSResolverCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ENSResolverTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type ENSResolverTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ENSResolverFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type ENSResolverFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// ENSResolverSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type ENSResolverSession struct {
+	Contract     *ENSResolver      // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// ENSResolverCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type ENSResolverCallerSession struct {
+	Contract *ENSResolverCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts      // Call options to use throughout this session
+}
+
+// ENSResolverTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type ENSResolverTransactorSession struct {
+	Contract     *ENSResolverTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts      // Transaction auth options to use throughout this session
+}
+
+// ENSResolverRaw is an auto generated low-level Go binding around an Ethereum contract.
+type ENSResolverRaw struct {
+	Contract *ENSResolver // Generic contract binding to access the raw methods on
+}
+
+// ENSResolverCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type ENSResolverCallerRaw struct {
+	Contract *ENSResolverCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// ENSResolverTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type ENSResolverTransactorRaw struct {
+	Contract *ENSResolverTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewENSResolver creates a new instance of ENSResolver, bound to a specific deployed contract.
+func NewENSResolver(address common.Address, backend bind.ContractBackend) (*ENSResolver, error) {
+	contract, err := bindENSResolver(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &ENSResolver{ENSResolverCaller: ENSResolverCaller{contract: contract}, ENSResolverTransactor: ENSResolverTransactor{contract: contract}, ENSResolverFilterer: ENSResolverFilterer{contract: contract}}, nil
+}
+
+// NewENSResolverCaller creates a new read-only instance of ENSResolver, bound to a specific deployed contract.
+func NewENSResolverCaller(address common.Address, caller bind.ContractCaller) (*ENSResolverCaller, error) {
+	contract, err := bindENSResolver(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ENSResolverCaller{contract: contract}, nil
+}
+
+// NewENSResolverTransactor creates a new write-only instance of ENSResolver, bound to a specific deployed contract.
+func NewENSResolverTransactor(address common.Address, transactor bind.ContractTransactor) (*ENSResolverTransactor, error) {
+	contract, err := bindENSResolver(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ENSResolverTransactor{contract: contract}, nil
+}
+
+// NewENSResolverFilterer creates a new log filterer instance of ENSResolver, bound to a specific deployed contract.
+func NewENSResolverFilterer(address common.Address, filterer bind.ContractFilterer) (*ENSResolverFilterer, error) {
+	contract, err := bindENSResolver(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &ENSResolverFilterer{contract: contract}, nil
+}
+
+// bindENSResolver binds a generic wrapper to an already deployed contract.
+func bindENSResolver(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := ENSResolverMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, *parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_ENSResolver *ENSResolverRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _ENSResolver.Contract.ENSResolverCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_ENSResolver *ENSResolverRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _ENSResolver.Contract.ENSResolverTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_ENSResolver *ENSResolverRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _ENSResolver.Contract.ENSResolverTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_ENSResolver *ENSResolverCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _ENSResolver.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_ENSResolver *ENSResolverTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _ENSResolver.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_ENSResolver *ENSResolverTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _ENSResolver.Contract.contract.Transact(opts, method, params...)
+}
+
+// Addr is a free data retrieval call binding the contract method 0x3b3b57de.
+//
+// Solidity: function addr(bytes32 node) view returns(address)
+func (_ENSResolver *ENSResolverCaller) Addr(opts *bind.CallOpts, node [32]byte) (common.Address, error) {
+	var out []interface{}
+	err := _ENSResolver.contract.Call(opts, &out, "addr", node)
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// Addr is a free data retrieval call binding the contract method 0x3b3b57de.
+//
+// Solidity: function addr(bytes32 node) view returns(address)
+func (_ENSResolver *ENSResolverSession) Addr(node [32]byte) (common.Address, error) {
+	return _ENSResolver.Contract.Addr(&_ENSResolver.CallOpts, node)
+}
+
+// Addr is a free data retrieval call binding the contract method 0x3b3b57de.
+//
+// Solidity: function addr(bytes32 node) view returns(address)
+func (_ENSResolver *ENSResolverCallerSession) Addr(node [32]byte) (common.Address, error) {
+	return _ENSResolver.Contract.Addr(&_ENSResolver.CallOpts, node)
+}
+
+// Name is a free data retrieval call binding the contract method 0x691f3431.
+//
+// Solidity: function name(bytes32 node) view returns(string)
+func (_ENSResolver *ENSResolverCaller) Name(opts *bind.CallOpts, node [32]byte) (string, error) {
+	var out []interface{}
+	err := _ENSResolver.contract.Call(opts, &out, "name", node)
+
+	if err != nil {
+		return *new(string), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(string)).(*string)
+
+	return out0, err
+
+}
+
+// Name is a free data retrieval call binding the contract method 0x691f3431.
+//
+// Solidity: function name(bytes32 node) view returns(string)
+func (_ENSResolver *ENSResolverSession) Name(node [32]byte) (string, error) {
+	return _ENSResolver.Contract.Name(&_ENSResolver.CallOpts, node)
+}
+
+// Name is a free data retrieval call binding the contract method 0x691f3431.
+//
+// Solidity: function name(bytes32 node) view returns(string)
+func (_ENSResolver *ENSResolverCallerSession) Name(node [32]byte) (string, error) {
+	return _ENSResolver.Contract.Name(&_ENSResolver.CallOpts, node)
+}
+
+// SetAddr is a paid mutator transaction binding the contract method 0xd5fa2b00.
+//
+// Solidity: function setAddr(bytes32 node, address addr_) returns()
+func (_ENSResolver *ENSResolverTransactor) SetAddr(opts *bind.TransactOpts, node [32]byte, addr_ common.Address) (*types.Transaction, error) {
+	return _ENSResolver.contract.Transact(opts, "setAddr", node, addr_)
+}
+
+// SetAddr is a paid mutator transaction binding the contract method 0xd5fa2b00.
+//
+// Solidity: function setAddr(bytes32 node, address addr_) returns()
+func (_ENSResolver *ENSResolverSession) SetAddr(node [32]byte, addr_ common.Address) (*types.Transaction, error) {
+	return _ENSResolver.Contract.SetAddr(&_ENSResolver.TransactOpts, node, addr_)
+}
+
+// SetAddr is a paid mutator transaction binding the contract method 0xd5fa2b00.
+//
+// Solidity: function setAddr(bytes32 node, address addr_) returns()
+func (_ENSResolver *ENSResolverTransactorSession) SetAddr(node [32]byte, addr_ common.Address) (*types.Transaction, error) {
+	return _ENSResolver.Contract.SetAddr(&_ENSResolver.TransactOpts, node, addr_)
+}
+
+// SetName is a paid mutator transaction binding the contract method 0x77372213.
+//
+// Solidity: function setName(bytes32 node, string name_) returns()
+func (_ENSResolver *ENSResolverTransactor) SetName(opts *bind.TransactOpts, node [32]byte, name_ string) (*types.Transaction, error) {
+	return _ENSResolver.contract.Transact(opts, "setName", node, name_)
+}
+
+// SetName is a paid mutator transaction binding the contract method 0x77372213.
+//
+// Solidity: function setName(bytes32 node, string name_) returns()
+func (_ENSResolver *ENSResolverSession) SetName(node [32]byte, name_ string) (*types.Transaction, error) {
+	return _ENSResolver.Contract.SetName(&_ENSResolver.TransactOpts, node, name_)
+}
+
+// SetName is a paid mutator transaction binding the contract method 0x77372213.
+//
+// Solidity: function setName(bytes32 node, string name_) returns()
+func (_ENSResolver *ENSResolverTransactorSession) SetName(node [32]byte, name_ string) (*types.Transaction, error) {
+	return _ENSResolver.Contract.SetName(&_ENSResolver.TransactOpts, node, name_)
+}
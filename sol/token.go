@@ -32,12 +32,34 @@ var (
 // TokenMetaData contains all meta data concerning the Token contract.
 var TokenMetaData = &bind.MetaData{
 	ABI: "[{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"Approval\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"Transfer\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"approve\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"mint\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"transfer\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"transferFrom\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"_sym\",\"type\":\"string\"}],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"internalType\":\"address\",\"name\":\"spender\",\"type\":\"address\"}],\"name\":\"allowance\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"}],\"name\":\"balanceOf\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"symbol\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"\",\"type\":\"string\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"totalSupply\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"}]",
+	Bin: "0x60806040523480156200001157600080fd5b5060405162000a1638038062000a16833981016040819052620000349162000072565b6004620000428282620001d6565b5050600380546001600160a01b03191633179055620002a2565b634e487b7160e01b600052604160045260246000fd5b600060208083850312156200008657600080fd5b82516001600160401b03808211156200009e57600080fd5b818501915085601f830112620000b357600080fd5b815181811115620000c857620000c86200005c565b604051601f8201601f19908116603f01168101908382118183101715620000f357620000f36200005c565b8160405282815288868487010111156200010c57600080fd5b600093505b8284101562000130578484018601518185018701529285019262000111565b600086848301015280965050505050505092915050565b600181811c908216806200015c57607f821691505b6020821081036200017d57634e487b7160e01b600052602260045260246000fd5b50919050565b601f821115620001d157600081815260208120601f850160051c81016020861015620001ac5750805b601f850160051c820191505b81811015620001cd57828155600101620001b8565b5050505b505050565b81516001600160401b03811115620001f257620001f26200005c565b6200020a8162000203845462000147565b8462000183565b602080601f831160018114620002425760008415620002295750858301515b600019600386901b1c1916600185901b178555620001cd565b600085815260208120601f198616915b82811015620002735788860151825594840194600190910190840162000252565b5085821015620002925787850151600019600388901b60f8161c191681555b5050505050600190811b01905550565b61076480620002b26000396000f3fe608060405234801561001057600080fd5b50600436106100885760003560e01c806370a082311161005b57806370a08231146100ef57806395d89b4114610118578063a9059cbb1461012d578063dd62ed3e1461014057600080fd5b8063095ea7b31461008d57806318160ddd146100b557806323b872dd146100c757806340c10f19146100da575b600080fd5b6100a061009b3660046105a0565b610179565b60405190151581526020015b60405180910390f35b6002545b6040519081526020016100ac565b6100a06100d53660046105ca565b6101f4565b6100ed6100e83660046105a0565b610352565b005b6100b96100fd366004610606565b6001600160a01b031660009081526020819052604090205490565b6101206103f1565b6040516100ac9190610621565b6100a061013b3660046105a0565b61047f565b6100b961014e36600461066f565b6001600160a01b03918216600090815260016020908152604080832093909416825291909152205490565b60006001600160a01b03831661018e57600080fd5b3360008181526001602090815260408083206001600160a01b03881680855290835292819020869055518581529192917f8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b92591015b60405180910390a35060015b92915050565b6001600160a01b03831660009081526020819052604081205482111561021957600080fd5b6001600160a01b038416600090815260016020908152604080832033845290915290205482111561024957600080fd5b6001600160a01b03831661025c57600080fd5b6001600160a01b03841660009081526020819052604090205461027f9083610542565b6001600160a01b0380861660009081526020819052604080822093909355908516815220546102ae9083610565565b6001600160a01b038085166000908152602081815260408083209490945591871681526001825282812033825290915220546102ea9083610542565b6001600160a01b03858116600081815260016020908152604080832033845282529182902094909455518581529186169290917fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef910160405180910390a35060019392505050565b6003546001600160a01b0316331461036957600080fd5b6002546103769082610565565b6002556001600160a01b03821660009081526020819052604090205461039c9082610565565b6001600160a01b038316600081815260208181526040808320949094559251848152919290917fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef910160405180910390a35050565b600480546103fe906106a2565b80601f016020809104026020016040519081016040528092919081815260200182805461042a906106a2565b80156104775780601f1061044c57610100808354040283529160200191610477565b820191906000526020600020905b81548152906001019060200180831161045a57829003601f168201915b505050505081565b3360009081526020819052604081205482111561049b57600080fd5b6001600160a01b0383166104ae57600080fd5b336000908152602081905260409020546104c89083610542565b33600090815260208190526040808220929092556001600160a01b038516815220546104f49083610565565b6001600160a01b038416600081815260208181526040918290209390935551848152909133917fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef91016101e2565b600082821115610554576105546106dc565b61055e8284610708565b9392505050565b600080610572838561071b565b90508381101561055e5761055e6106dc565b80356001600160a01b038116811461059b57600080fd5b919050565b600080604083850312156105b357600080fd5b6105bc83610584565b946020939093013593505050565b6000806000606084860312156105df57600080fd5b6105e884610584565b92506105f660208501610584565b9150604084013590509250925092565b60006020828403121561061857600080fd5b61055e82610584565b600060208083528351808285015260005b8181101561064e57858101830151858201604001528201610632565b506000604082860101526040601f19601f8301168501019250505092915050565b6000806040838503121561068257600080fd5b61068b83610584565b915061069960208401610584565b90509250929050565b600181811c908216806106b657607f821691505b6020821081036106d657634e487b7160e01b600052602260045260246000fd5b50919050565b634e487b7160e01b600052600160045260246000fd5b634e487b7160e01b600052601160045260246000fd5b818103818111156101ee576101ee6106f2565b808201808211156101ee576101ee6106f256fea264697066735822122061775e5c1e3e2f54add38601e3c3027e0fc9386c87b4af67bcaaab21c59f933d64736f6c63430008150033",
 }
 
 // TokenABI is the input ABI used to generate the binding from.
 // Deprecated: Use TokenMetaData.ABI instead.
 var TokenABI = TokenMetaData.ABI
 
+// TokenBin is the compiled bytecode used for deploying new contracts.
+// Deprecated: Use TokenMetaData.Bin instead.
+var TokenBin = TokenMetaData.Bin
+
+// DeployToken deploys a new Ethereum contract, binding an instance of Token to it.
+func DeployToken(auth *bind.TransactOpts, backend bind.ContractBackend, _sym string) (common.Address, *types.Transaction, *Token, error) {
+	parsed, err := TokenMetaData.GetAbi()
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	if parsed == nil {
+		return common.Address{}, nil, nil, errors.New("GetABI returned nil")
+	}
+
+	address, tx, contract, err := bind.DeployContract(auth, *parsed, common.FromHex(TokenBin), backend, _sym)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	return address, tx, &Token{TokenCaller: TokenCaller{contract: contract}, TokenTransactor: TokenTransactor{contract: contract}, TokenFilterer: TokenFilterer{contract: contract}}, nil
+}
+
 // Token is an auto generated Go binding around an Ethereum contract.
 type Token struct {
 	TokenCaller     // Read-only binding to the contract
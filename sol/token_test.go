@@ -0,0 +1,118 @@
+package sol
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// newSimulated 创建一个模拟链，返回预先充值的部署账户。
+func newSimulated(t *testing.T) (*simulated.Backend, *bind.TransactOpts) {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := bind.NewKeyedTransactorWithChainID(key, params.AllDevChainProtocolChanges.ChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance := new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))
+	sim := simulated.NewBackend(types.GenesisAlloc{auth.From: {Balance: balance}})
+	t.Cleanup(func() { sim.Close() })
+	return sim, auth
+}
+
+// mined 出块并检查交易执行成功。
+func mined(t *testing.T, sim *simulated.Backend, tx *types.Transaction) {
+	t.Helper()
+	sim.Commit()
+	receipt, err := sim.Client().TransactionReceipt(context.Background(), tx.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("transaction %s failed", tx.Hash().Hex())
+	}
+}
+
+func TestDeployAndMint(t *testing.T) {
+	sim, owner := newSimulated(t)
+	// 超过 31 字节的符号按 Solidity 长字符串保存，需要和编译器的存储布局一致。
+	symbol := "A_TOKEN_SYMBOL_LONGER_THAN_ONE_STORAGE_SLOT"
+	_, tx, token, err := DeployToken(owner, sim.Client(), symbol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mined(t, sim, tx)
+
+	got, err := token.Symbol(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != symbol {
+		t.Fatalf("symbol = %q, want %q", got, symbol)
+	}
+
+	to := common.HexToAddress("0x703c4b2bD70c169f5717101CaeE543299Fc946C7")
+	amounts := []int64{1000, 234}
+	for _, amount := range amounts {
+		tx, err := token.Mint(owner, to, big.NewInt(amount))
+		if err != nil {
+			t.Fatal(err)
+		}
+		mined(t, sim, tx)
+	}
+
+	balance, err := token.BalanceOf(nil, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	supply, err := token.TotalSupply(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Int64() != 1234 || supply.Int64() != 1234 {
+		t.Fatalf("balance = %s, total supply = %s, want 1234", balance, supply)
+	}
+}
+
+func TestMintOnlyOwner(t *testing.T) {
+	sim, owner := newSimulated(t)
+	address, tx, _, err := DeployToken(owner, sim.Client(), "GWT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mined(t, sim, tx)
+
+	// 模拟其他账户调用 mint，合约应当回滚。
+	parsed, err := TokenMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	caller := bind.NewBoundContract(address, *parsed, sim.Client(), sim.Client(), sim.Client())
+	stranger := common.HexToAddress("0x2c7536E3605D9C16a7a3D7b1898e529396a65c23")
+	var out []interface{}
+	err = caller.Call(&bind.CallOpts{From: stranger}, &out, "mint", stranger, big.NewInt(1))
+	if err == nil {
+		t.Fatal("mint by a non-owner succeeded")
+	}
+	token, err := NewTokenCaller(address, sim.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	supply, err := token.TotalSupply(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if supply.Sign() != 0 {
+		t.Fatalf("total supply = %s, want 0", supply)
+	}
+}
@@ -0,0 +1,144 @@
+package units
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// EtherDecimals 是以太币相对于 wei 的小数位数。
+const EtherDecimals = 18
+
+// etherUnits 是以太币单位到其相对于 wei 的小数位数的映射。
+var etherUnits = map[string]int{
+	"wei":    0,
+	"kwei":   3,
+	"mwei":   6,
+	"gwei":   9,
+	"szabo":  12,
+	"finney": 15,
+	"ether":  18,
+	"eth":    18,
+}
+
+// ParseEther 将带单位的十进制金额字符串解析为 wei。
+// 参数:
+//
+//	s - 金额字符串，例如 "1.5ether"、"20gwei"、"100wei"，不带单位时按 ether 处理。
+//
+// 返回值:
+//
+//	*big.Int - 以 wei 为单位的金额。
+//	error - 如果格式错误、为负数或小数位超出单位精度，则返回错误信息。
+func ParseEther(s string) (*big.Int, error) {
+	number, unit := splitUnit(s)
+	decimals := EtherDecimals
+	if unit != "" {
+		d, ok := etherUnits[unit]
+		if !ok {
+			return nil, fmt.Errorf("unknown unit %q in amount %q", unit, s)
+		}
+		decimals = d
+	}
+	return ParseUnits(number, decimals)
+}
+
+// ParseUnits 将十进制金额字符串按给定的小数位数放大为最小单位的整数，不经过浮点数运算。
+// 参数:
+//
+//	s - 十进制金额字符串，例如 "12.5"。
+//	decimals - 小数位数，例如代币的 decimals。
+//
+// 返回值:
+//
+//	*big.Int - 以最小单位表示的金额。
+//	error - 如果格式错误、为负数或小数位超出精度，则返回错误信息。
+func ParseUnits(s string, decimals int) (*big.Int, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), "_", "")
+	if s == "" {
+		return nil, fmt.Errorf("empty amount")
+	}
+	if strings.HasPrefix(s, "-") {
+		return nil, fmt.Errorf("negative amount %q", s)
+	}
+	s = strings.TrimPrefix(s, "+")
+	integer, fraction, _ := strings.Cut(s, ".")
+	if integer == "" && fraction == "" {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	if !isDigits(integer) || !isDigits(fraction) {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > decimals {
+		return nil, fmt.Errorf("amount %q has more than %d decimal places", s, decimals)
+	}
+	digits := integer + fraction + strings.Repeat("0", decimals-len(fraction))
+	if digits == "" {
+		digits = "0"
+	}
+	value, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	return value, nil
+}
+
+// FormatEther 将 wei 格式化为以 ether 为单位的十进制字符串，去掉多余的末尾零。
+func FormatEther(wei *big.Int) string {
+	return FormatUnits(wei, EtherDecimals)
+}
+
+// FormatUnits 将最小单位的整数按给定的小数位数格式化为十进制字符串，去掉多余的末尾零。
+// 参数:
+//
+//	value - 以最小单位表示的金额。
+//	decimals - 小数位数。
+//
+// 返回值:
+//
+//	string - 十进制金额字符串，例如 "1.5"。
+func FormatUnits(value *big.Int, decimals int) string {
+	if value == nil {
+		return "0"
+	}
+	sign := ""
+	if value.Sign() < 0 {
+		sign = "-"
+	}
+	digits := new(big.Int).Abs(value).String()
+	if decimals <= 0 {
+		return sign + digits
+	}
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+	integer := digits[:len(digits)-decimals]
+	fraction := strings.TrimRight(digits[len(digits)-decimals:], "0")
+	if fraction == "" {
+		return sign + integer
+	}
+	return sign + integer + "." + fraction
+}
+
+// splitUnit 将金额字符串拆分为数字部分和小写的单位部分。
+func splitUnit(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '_' && r != '-' && r != '+'
+	})
+	if i < 0 {
+		return s, ""
+	}
+	return strings.TrimSpace(s[:i]), strings.ToLower(strings.TrimSpace(s[i:]))
+}
+
+// isDigits 判断字符串是否只包含十进制数字，空字符串也视为合法。
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -2,9 +2,11 @@ package utils
 
 import (
 	"crypto/rand"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // UUID 是一个字节切片，用于表示UUID。
@@ -61,3 +63,33 @@ func WriteKeyFile(file string, content []byte) error {
 	f.Close()
 	return os.Rename(f.Name(), file)
 }
+
+// ErrLocked 表示锁文件在等待时间内一直被其他进程持有。
+var ErrLocked = errors.New("locked by another process")
+
+// LockFile 通过独占创建锁文件实现进程间互斥，返回释放锁的函数。
+// 锁文件存在超过 stale 时视为进程异常退出后遗留的锁并删除；等待超过 timeout 时返回 ErrLocked。
+func LockFile(file string, timeout, stale time.Duration) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(file) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(file); err == nil && time.Since(info.ModTime()) > stale {
+			os.Remove(file)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrLocked
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}